@@ -0,0 +1,38 @@
+package enproto
+
+import "github.com/ianchildress/enproto/internal/bufpool"
+
+// Allocator supplies the buffers that payloads are read into, so arenas, off-heap
+// memory or instrumented allocators can stand in for the built-in buffer pool.
+//
+// Alloc returns a slice of length n; its contents need not be zeroed. Free is handed
+// back exactly the slice Alloc returned, once the reader is done lending it.
+// Implementations must be safe for concurrent use if payloads are released from
+// other goroutines.
+type Allocator interface {
+	Alloc(n int) []byte
+	Free(buf []byte)
+}
+
+// PoolAllocator returns the Allocator used by default in OwnershipBorrow mode: the
+// process-wide size-classed pool reported by BufferPoolStats.
+func PoolAllocator() Allocator {
+	return poolAllocator{}
+}
+
+type poolAllocator struct{}
+
+func (poolAllocator) Alloc(n int) []byte { return bufpool.Get(n) }
+func (poolAllocator) Free(buf []byte)    { bufpool.Put(buf) }
+
+// WithAllocator sets the Allocator payload buffers come from.
+//
+// In OwnershipBorrow mode payloads are allocated with a and freed when passed to
+// Release; the default is PoolAllocator. In OwnershipCopy mode payloads are allocated
+// with a and then belong to the caller, who may Free them when done; without this
+// option they are ordinary heap slices.
+func WithAllocator(a Allocator) Option {
+	return func(c *config) {
+		c.allocator = a
+	}
+}
@@ -0,0 +1,75 @@
+package enproto
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// countingAllocator records Alloc and Free calls for the tests below.
+type countingAllocator struct {
+	mu     sync.Mutex
+	allocs int
+	frees  int
+}
+
+func (a *countingAllocator) Alloc(n int) []byte {
+	a.mu.Lock()
+	a.allocs++
+	a.mu.Unlock()
+	return make([]byte, n)
+}
+
+func (a *countingAllocator) Free(buf []byte) {
+	a.mu.Lock()
+	a.frees++
+	a.mu.Unlock()
+}
+
+// TestWithAllocator_Borrow checks that borrowed payloads are allocated and freed
+// through the configured allocator, including empty ones.
+func TestWithAllocator_Borrow(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	fw.WriteFrame(0x01, []byte("hello"))
+	fw.WriteFrame(0x02, nil)
+
+	alloc := &countingAllocator{}
+	fr := NewFrameReader(&buf, WithPayloadOwnership(OwnershipBorrow), WithAllocator(alloc))
+	for i := 0; i < 2; i++ {
+		_, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame: %v", err)
+		}
+		if err := fr.Release(payload); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+	}
+
+	if alloc.allocs != 2 || alloc.frees != 2 {
+		t.Errorf("allocs, frees = %d, %d; want 2, 2", alloc.allocs, alloc.frees)
+	}
+}
+
+// TestWithAllocator_Copy checks that copied payloads come from the allocator and are
+// left for the caller to free.
+func TestWithAllocator_Copy(t *testing.T) {
+	var buf bytes.Buffer
+	NewFrameWriter(&buf).WriteFrame(0x01, []byte("hello"))
+
+	alloc := &countingAllocator{}
+	fr := NewFrameReader(&buf, WithAllocator(alloc))
+	_, payload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	if string(payload) != "hello" {
+		t.Errorf("payload = %q; want %q", payload, "hello")
+	}
+	if err := fr.Release(payload); err != nil {
+		t.Errorf("Release in copy mode: %v", err)
+	}
+	if alloc.allocs != 1 || alloc.frees != 0 {
+		t.Errorf("allocs, frees = %d, %d; want 1, 0", alloc.allocs, alloc.frees)
+	}
+}
@@ -0,0 +1,178 @@
+// Package batch delivers groups of enproto frames atomically.
+//
+// A writer brackets a group of frames with BEGIN and COMMIT control frames, each
+// carrying the number of frames in the batch as a 4-byte big-endian count. A Reader
+// holds frames back until the matching COMMIT arrives and hands the whole batch over
+// at once; batches that are cut short or do not add up are discarded. The control
+// frame types are reserved on connections that use batches.
+package batch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Control frame types.
+const (
+	TypeBegin  byte = 0xF8
+	TypeCommit byte = 0xF9
+)
+
+// ErrDiscarded is wrapped by Reader errors for batches that were dropped because they
+// did not arrive intact.
+var ErrDiscarded = errors.New("batch: discarded incomplete batch")
+
+// Write sends frames as a single batch and flushes once at the end.
+func Write(w *enproto.FrameWriter, frames []enproto.Frame) error {
+	var count [4]byte
+	binary.BigEndian.PutUint32(count[:], uint32(len(frames)))
+
+	if err := w.WriteFrameBuffered(TypeBegin, count[:]); err != nil {
+		return err
+	}
+	for _, f := range frames {
+		if err := w.WriteFrameBuffered(f.Type, f.Payload); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteFrameBuffered(TypeCommit, count[:]); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Option configures a Reader.
+type Option func(*Reader)
+
+// WithMaxFrames sets the most frames a batch may hold. BEGIN frames declaring more
+// are refused. The default is 4096.
+func WithMaxFrames(n uint32) Option {
+	return func(br *Reader) {
+		br.maxFrames = n
+	}
+}
+
+// WithMaxBytes sets the most payload bytes a batch may hold. The default is 64 MiB.
+func WithMaxBytes(n int) Option {
+	return func(br *Reader) {
+		br.maxBytes = n
+	}
+}
+
+// Reader reads frames and batches from a FrameReader. The FrameReader must use the
+// default OwnershipCopy mode, since frames are held until their batch commits.
+type Reader struct {
+	r         *enproto.FrameReader
+	maxFrames uint32
+	maxBytes  int
+
+	open         bool
+	want         uint32
+	pending      []enproto.Frame
+	pendingBytes int
+
+	skipping bool // dropping the rest of a refused batch until its COMMIT
+}
+
+// NewReader returns a Reader reading from r, configured by opts.
+func NewReader(r *enproto.FrameReader, opts ...Option) *Reader {
+	br := &Reader{r: r, maxFrames: 4096, maxBytes: 64 << 20}
+	for _, opt := range opts {
+		opt(br)
+	}
+	return br
+}
+
+// Next returns the next unit of delivery: a single frame sent outside any batch, or
+// every frame of a committed batch. If the stream fails while a batch is open, the
+// partial batch is dropped and the read error returned. A batch whose COMMIT does not
+// match its BEGIN or its frame count is dropped and reported with ErrDiscarded;
+// reading may continue after that. A batch is also dropped, and its remaining frames
+// up to the COMMIT skipped, when its BEGIN declares more frames than WithMaxFrames
+// allows, or as soon as it holds more frames than declared or more bytes than
+// WithMaxBytes allows.
+func (br *Reader) Next() ([]enproto.Frame, error) {
+	for {
+		msgType, payload, err := br.r.ReadFrame()
+		if err != nil {
+			br.reset()
+			return nil, err
+		}
+
+		switch msgType {
+		case TypeBegin:
+			count, err := parseCount(payload)
+			if err != nil {
+				br.reset()
+				return nil, err
+			}
+			wasOpen := br.open
+			br.reset()
+			br.skipping = false
+			if count > br.maxFrames {
+				br.skipping = true
+				return nil, fmt.Errorf("%w: %d frames declared, limit %d", ErrDiscarded, count, br.maxFrames)
+			}
+			br.open, br.want = true, count
+			if wasOpen {
+				return nil, fmt.Errorf("%w: BEGIN before COMMIT", ErrDiscarded)
+			}
+
+		case TypeCommit:
+			count, err := parseCount(payload)
+			if err != nil {
+				br.reset()
+				return nil, err
+			}
+			if br.skipping {
+				br.skipping = false // already reported
+				continue
+			}
+			if !br.open {
+				return nil, fmt.Errorf("%w: COMMIT without BEGIN", ErrDiscarded)
+			}
+			frames, want := br.pending, br.want
+			br.reset()
+			if count != want || uint32(len(frames)) != want {
+				return nil, fmt.Errorf("%w: got %d frames, want %d", ErrDiscarded, len(frames), want)
+			}
+			return frames, nil
+
+		default:
+			frame := enproto.Frame{Type: msgType, Payload: payload}
+			if br.skipping {
+				continue
+			}
+			if !br.open {
+				return []enproto.Frame{frame}, nil
+			}
+			if uint32(len(br.pending)) == br.want {
+				want := br.want
+				br.reset()
+				br.skipping = true
+				return nil, fmt.Errorf("%w: more than %d frames", ErrDiscarded, want)
+			}
+			if br.pendingBytes+len(payload) > br.maxBytes {
+				br.reset()
+				br.skipping = true
+				return nil, fmt.Errorf("%w: more than %d bytes", ErrDiscarded, br.maxBytes)
+			}
+			br.pending = append(br.pending, frame)
+			br.pendingBytes += len(payload)
+		}
+	}
+}
+
+func (br *Reader) reset() {
+	br.open, br.want, br.pending, br.pendingBytes = false, 0, nil, 0
+}
+
+func parseCount(payload []byte) (uint32, error) {
+	if len(payload) != 4 {
+		return 0, fmt.Errorf("%w: malformed control frame", ErrDiscarded)
+	}
+	return binary.BigEndian.Uint32(payload), nil
+}
@@ -0,0 +1,195 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestWriteNext verifies batches are delivered whole and loose frames individually.
+func TestWriteNext(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(buf)
+
+	if err := fw.WriteFrame(0x1, []byte("loose")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	frames := []enproto.Frame{
+		{Type: 0x2, Payload: []byte("part 1")},
+		{Type: 0x3, Payload: []byte("part 2")},
+	}
+	if err := Write(fw, frames); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+
+	br := NewReader(enproto.NewFrameReader(buf))
+
+	got, err := br.Next()
+	if err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Payload) != "loose" {
+		t.Errorf("first unit = %v; want the loose frame", got)
+	}
+
+	got, err = br.Next()
+	if err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if len(got) != len(frames) {
+		t.Fatalf("batch has %d frames; want %d", len(got), len(frames))
+	}
+	for i := range frames {
+		if got[i].Type != frames[i].Type || !bytes.Equal(got[i].Payload, frames[i].Payload) {
+			t.Errorf("frame %d = %v; want %v", i, got[i], frames[i])
+		}
+	}
+
+	if _, err := br.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+// TestNext_Truncated ensures a batch cut off by the end of stream is never delivered.
+func TestNext_Truncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(buf)
+	if err := fw.WriteFrame(TypeBegin, []byte{0, 0, 0, 2}); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if err := fw.WriteFrame(0x2, []byte("orphan")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	got, err := NewReader(enproto.NewFrameReader(buf)).Next()
+	if !errors.Is(err, io.EOF) || got != nil {
+		t.Errorf("Next = %v, %v; want nil, io.EOF", got, err)
+	}
+}
+
+// TestNext_CountMismatch ensures a batch missing frames is discarded and reading continues.
+func TestNext_CountMismatch(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(buf)
+	for _, f := range []enproto.Frame{
+		{Type: TypeBegin, Payload: []byte{0, 0, 0, 2}},
+		{Type: 0x2, Payload: []byte("only one")},
+		{Type: TypeCommit, Payload: []byte{0, 0, 0, 2}},
+		{Type: 0x4, Payload: []byte("after")},
+	} {
+		if err := fw.WriteFrame(f.Type, f.Payload); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	br := NewReader(enproto.NewFrameReader(buf))
+	if _, err := br.Next(); !errors.Is(err, ErrDiscarded) {
+		t.Errorf("expected ErrDiscarded, got %v", err)
+	}
+
+	got, err := br.Next()
+	if err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Payload) != "after" {
+		t.Errorf("unit after discard = %v; want the loose frame", got)
+	}
+}
+
+// TestNext_NestedBegin ensures a BEGIN inside an open batch discards it and starts a new one.
+func TestNext_NestedBegin(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(buf)
+	if err := fw.WriteFrame(TypeBegin, []byte{0, 0, 0, 5}); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if err := Write(fw, []enproto.Frame{{Type: 0x2, Payload: []byte("kept")}}); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+
+	br := NewReader(enproto.NewFrameReader(buf))
+	if _, err := br.Next(); !errors.Is(err, ErrDiscarded) {
+		t.Errorf("expected ErrDiscarded, got %v", err)
+	}
+	got, err := br.Next()
+	if err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Payload) != "kept" {
+		t.Errorf("batch = %v; want [kept]", got)
+	}
+}
+
+// TestNext_Overlong ensures a batch is discarded as soon as it exceeds its declared
+// count, and its remaining frames are skipped up to the COMMIT.
+func TestNext_Overlong(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(buf)
+	for _, f := range []enproto.Frame{
+		{Type: TypeBegin, Payload: []byte{0, 0, 0, 1}},
+		{Type: 0x2, Payload: []byte("one")},
+		{Type: 0x2, Payload: []byte("two")},
+		{Type: 0x2, Payload: []byte("three")},
+		{Type: TypeCommit, Payload: []byte{0, 0, 0, 1}},
+		{Type: 0x4, Payload: []byte("after")},
+	} {
+		if err := fw.WriteFrame(f.Type, f.Payload); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	br := NewReader(enproto.NewFrameReader(buf))
+	if _, err := br.Next(); !errors.Is(err, ErrDiscarded) {
+		t.Errorf("expected ErrDiscarded, got %v", err)
+	}
+	if n := len(br.pending); n != 0 {
+		t.Errorf("%d frames still held after the discard", n)
+	}
+
+	got, err := br.Next()
+	if err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Payload) != "after" {
+		t.Errorf("unit after discard = %v; want the loose frame", got)
+	}
+}
+
+// TestNext_Limits ensures batches over the reader's frame or byte limit are refused
+// and skipped, and reading continues after them.
+func TestNext_Limits(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(buf)
+	for _, f := range []enproto.Frame{
+		{Type: TypeBegin, Payload: []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{Type: 0x2, Payload: []byte("skipped")},
+		{Type: TypeCommit, Payload: []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{Type: TypeBegin, Payload: []byte{0, 0, 0, 2}},
+		{Type: 0x2, Payload: []byte("0123456789")},
+		{Type: 0x2, Payload: []byte("0123456789")},
+		{Type: TypeCommit, Payload: []byte{0, 0, 0, 2}},
+		{Type: 0x4, Payload: []byte("after")},
+	} {
+		if err := fw.WriteFrame(f.Type, f.Payload); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	br := NewReader(enproto.NewFrameReader(buf), WithMaxFrames(8), WithMaxBytes(15))
+	for _, limit := range []string{"frames", "bytes"} {
+		if _, err := br.Next(); !errors.Is(err, ErrDiscarded) {
+			t.Errorf("batch over the %s limit: got %v; want ErrDiscarded", limit, err)
+		}
+	}
+
+	got, err := br.Next()
+	if err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Payload) != "after" {
+		t.Errorf("unit after the refused batches = %v; want the loose frame", got)
+	}
+}
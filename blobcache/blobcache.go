@@ -0,0 +1,194 @@
+// Package blobcache saves bandwidth on repeated large payloads by sending them by
+// content hash once the peer has them cached.
+//
+// For a payload at or above the threshold, the sender transmits a REF frame carrying
+// the frame's type and the payload's SHA-256 hash: [1B type][32B hash]. A receiver
+// with the payload cached delivers it straight away. Otherwise it answers with a MISS
+// frame, [32B hash], and the sender replies with a BODY frame carrying the payload,
+// which the receiver caches and delivers with the type of every REF waiting for it.
+// Both ends keep a bounded LRU cache of the payloads they have sent or received.
+//
+// A payload whose REF missed is delivered when its BODY arrives, so it may be
+// delivered after frames sent behind it. The control frame types are reserved on
+// connections that use the cache.
+package blobcache
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Control frame types.
+const (
+	TypeRef  byte = 0xE8
+	TypeMiss byte = 0xE9
+	TypeBody byte = 0xEA
+)
+
+var (
+	ErrMalformed = errors.New("blobcache: malformed control frame")
+
+	// ErrEvicted is returned when the peer asks for a payload this end no longer has
+	// in its send cache. Size the caches so a payload outlives its round trip.
+	ErrEvicted = errors.New("blobcache: requested payload evicted")
+
+	// ErrTooManyPending is returned for a REF that would exceed the limits set by
+	// WithMaxPending on REFs waiting for their BODY.
+	ErrTooManyPending = errors.New("blobcache: too many REFs awaiting their BODY")
+)
+
+type hash = [sha256.Size]byte
+
+// Option configures a Conn.
+type Option func(*Conn)
+
+// WithThreshold sets the payload size, in bytes, from which payloads are sent by
+// reference. The default is 4 KiB.
+func WithThreshold(n int) Option {
+	return func(c *Conn) {
+		c.threshold = n
+	}
+}
+
+// WithCacheSize sets how many payload bytes each of the send and receive caches
+// holds. The default is 64 MiB.
+func WithCacheSize(bytes int) Option {
+	return func(c *Conn) {
+		c.sent.limit = bytes
+		c.received.limit = bytes
+	}
+}
+
+// WithMaxPending limits the REFs that may wait for a BODY: misses is how many
+// distinct hashes may be outstanding, and perHash how many REFs may wait on one hash.
+// REFs past either limit fail with ErrTooManyPending. The defaults are 1024 and 64.
+func WithMaxPending(misses, perHash int) Option {
+	return func(c *Conn) {
+		c.maxMisses = misses
+		c.maxPerHash = perHash
+	}
+}
+
+// Conn is one end of a connection using the cache. All writes to the connection must
+// go through Send, since the Conn answers the peer's control frames on the same writer.
+type Conn struct {
+	deliver   enproto.Handler
+	threshold int
+
+	wmu sync.Mutex // serialises writes from Send and ServeFrame
+	fw  *enproto.FrameWriter
+
+	sent     lru
+	received lru
+
+	mu         sync.Mutex
+	pending    map[hash][]byte // types of refs that missed, awaiting their BODY
+	maxMisses  int
+	maxPerHash int
+}
+
+// New returns a Conn writing to fw and handing delivered frames to deliver.
+func New(fw *enproto.FrameWriter, deliver enproto.Handler, opts ...Option) *Conn {
+	c := &Conn{
+		deliver:   deliver,
+		threshold: 4 << 10,
+		fw:        fw,
+		sent:      lru{limit: 64 << 20},
+		received:  lru{limit: 64 << 20},
+		pending:   make(map[hash][]byte),
+
+		maxMisses:  1024,
+		maxPerHash: 64,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Send writes a frame, by reference if its payload is at or above the threshold. The
+// payload is retained in the send cache and must not be modified afterwards. Payloads
+// too large for the send cache are always sent inline, since a MISS for them could
+// never be answered.
+func (c *Conn) Send(msgType byte, payload []byte) error {
+	if len(payload) < c.threshold || len(payload) > c.sent.limit {
+		return c.write(msgType, payload)
+	}
+
+	h := sha256.Sum256(payload)
+	c.sent.put(h, payload)
+
+	ref := make([]byte, 0, 1+len(h))
+	ref = append(ref, msgType)
+	ref = append(ref, h[:]...)
+	return c.write(TypeRef, ref)
+}
+
+// ServeFrame handles a frame read from the peer: control frames are processed and
+// every other frame, like the payloads REF and BODY frames resolve to, goes to the
+// deliver Handler. Payloads must be owned by the caller (the default OwnershipCopy
+// mode), as received bodies are cached.
+func (c *Conn) ServeFrame(msgType byte, payload []byte) error {
+	switch msgType {
+	case TypeRef:
+		if len(payload) != 1+sha256.Size {
+			return ErrMalformed
+		}
+		var h hash
+		copy(h[:], payload[1:])
+		if body, ok := c.received.get(h); ok {
+			return c.deliver.ServeFrame(payload[0], body)
+		}
+
+		c.mu.Lock()
+		waiting := c.pending[h]
+		first := len(waiting) == 0
+		if (first && len(c.pending) >= c.maxMisses) || len(waiting) >= c.maxPerHash {
+			c.mu.Unlock()
+			return ErrTooManyPending
+		}
+		c.pending[h] = append(waiting, payload[0])
+		c.mu.Unlock()
+		if !first {
+			return nil // a MISS for this hash is already outstanding
+		}
+		return c.write(TypeMiss, h[:])
+
+	case TypeMiss:
+		if len(payload) != sha256.Size {
+			return ErrMalformed
+		}
+		var h hash
+		copy(h[:], payload)
+		body, ok := c.sent.get(h)
+		if !ok {
+			return ErrEvicted
+		}
+		return c.write(TypeBody, body)
+
+	case TypeBody:
+		h := sha256.Sum256(payload)
+		c.received.put(h, payload)
+
+		c.mu.Lock()
+		types := c.pending[h]
+		delete(c.pending, h)
+		c.mu.Unlock()
+		for _, t := range types {
+			if err := c.deliver.ServeFrame(t, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.deliver.ServeFrame(msgType, payload)
+}
+
+func (c *Conn) write(msgType byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.fw.WriteFrame(msgType, payload)
+}
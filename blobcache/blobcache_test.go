@@ -0,0 +1,156 @@
+package blobcache
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// countingConn counts bytes written through a net.Conn.
+type countingConn struct {
+	net.Conn
+	n atomic.Int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	c.n.Add(int64(len(p)))
+	return c.Conn.Write(p)
+}
+
+// serve feeds frames read from fr to c until the connection ends.
+func serve(fr *enproto.FrameReader, c *Conn) {
+	for {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			return
+		}
+		if err := c.ServeFrame(msgType, payload); err != nil {
+			return
+		}
+	}
+}
+
+// TestConn_RepeatedPayload verifies a repeated large payload crosses the wire once.
+func TestConn_RepeatedPayload(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	counted := &countingConn{Conn: a}
+
+	got := make(chan enproto.Frame, 4)
+	sender := New(enproto.NewFrameWriter(counted), enproto.HandlerFunc(func(byte, []byte) error { return nil }))
+	receiver := New(enproto.NewFrameWriter(b), enproto.HandlerFunc(func(t byte, p []byte) error {
+		got <- enproto.Frame{Type: t, Payload: p}
+		return nil
+	}))
+	go serve(enproto.NewFrameReader(a), sender)
+	go serve(enproto.NewFrameReader(b), receiver)
+
+	blob := bytes.Repeat([]byte("snapshot"), 2048) // 16 KiB
+	if err := sender.Send(0x01, blob); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if f := <-got; f.Type != 0x01 || !bytes.Equal(f.Payload, blob) {
+		t.Fatalf("first delivery: type %d, %d bytes; want type 1, %d bytes", f.Type, len(f.Payload), len(blob))
+	}
+	firstCost := counted.n.Load()
+
+	if err := sender.Send(0x02, blob); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if f := <-got; f.Type != 0x02 || !bytes.Equal(f.Payload, blob) {
+		t.Fatalf("second delivery: type %d, %d bytes; want type 2, %d bytes", f.Type, len(f.Payload), len(blob))
+	}
+	if secondCost := counted.n.Load() - firstCost; secondCost > 64 {
+		t.Errorf("second send wrote %d bytes; want only a reference", secondCost)
+	}
+
+	if err := sender.Send(0x03, []byte("small")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if f := <-got; f.Type != 0x03 || string(f.Payload) != "small" {
+		t.Errorf("small delivery = %v; want type 3 %q", f, "small")
+	}
+}
+
+// TestConn_Evicted verifies a MISS for an unknown hash fails.
+func TestConn_Evicted(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(enproto.NewFrameWriter(&buf), enproto.HandlerFunc(func(byte, []byte) error { return nil }))
+	if err := c.ServeFrame(TypeMiss, make([]byte, 32)); !errors.Is(err, ErrEvicted) {
+		t.Errorf("ServeFrame(MISS) = %v; want %v", err, ErrEvicted)
+	}
+	if err := c.ServeFrame(TypeRef, []byte{1}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("ServeFrame(short REF) = %v; want %v", err, ErrMalformed)
+	}
+}
+
+// TestConn_LargerThanCache verifies payloads too big for the send cache go inline
+// rather than as a REF that could never be resolved.
+func TestConn_LargerThanCache(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(enproto.NewFrameWriter(&buf), nil, WithThreshold(16), WithCacheSize(64))
+	blob := bytes.Repeat([]byte("x"), 100)
+	if err := c.Send(0x01, blob); err != nil {
+		t.Fatal(err)
+	}
+
+	msgType, payload, err := enproto.NewFrameReader(&buf).ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msgType != 0x01 || !bytes.Equal(payload, blob) {
+		t.Errorf("sent type %#x with %d bytes; want the payload inline as type 0x01", msgType, len(payload))
+	}
+}
+
+// TestConn_MaxPending verifies REFs past the outstanding-miss limits are refused.
+func TestConn_MaxPending(t *testing.T) {
+	c := New(enproto.NewFrameWriter(&bytes.Buffer{}), nil, WithMaxPending(2, 3))
+	ref := func(msgType, id byte) []byte {
+		p := make([]byte, 33)
+		p[0], p[1] = msgType, id
+		return p
+	}
+
+	for i := byte(0); i < 3; i++ {
+		if err := c.ServeFrame(TypeRef, ref(i, 1)); err != nil {
+			t.Fatalf("REF %d for hash 1: %v", i, err)
+		}
+	}
+	if err := c.ServeFrame(TypeRef, ref(3, 1)); !errors.Is(err, ErrTooManyPending) {
+		t.Errorf("fourth REF for one hash = %v; want ErrTooManyPending", err)
+	}
+	if err := c.ServeFrame(TypeRef, ref(0, 2)); err != nil {
+		t.Fatalf("REF for hash 2: %v", err)
+	}
+	if err := c.ServeFrame(TypeRef, ref(0, 3)); !errors.Is(err, ErrTooManyPending) {
+		t.Errorf("third outstanding hash = %v; want ErrTooManyPending", err)
+	}
+}
+
+// TestLRU_Limit verifies the cache evicts least recently used payloads by size.
+func TestLRU_Limit(t *testing.T) {
+	c := lru{limit: 10}
+	c.put(hash{1}, make([]byte, 4))
+	c.put(hash{2}, make([]byte, 4))
+	c.get(hash{1}) // 2 is now least recently used
+	c.put(hash{3}, make([]byte, 4))
+
+	if _, ok := c.get(hash{2}); ok {
+		t.Errorf("least recently used entry was kept")
+	}
+	for _, k := range []hash{{1}, {3}} {
+		if _, ok := c.get(k); !ok {
+			t.Errorf("entry %d evicted; want kept", k[0])
+		}
+	}
+	c.put(hash{4}, make([]byte, 11))
+	if _, ok := c.get(hash{4}); ok {
+		t.Errorf("payload over the limit was cached")
+	}
+}
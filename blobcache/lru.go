@@ -0,0 +1,62 @@
+package blobcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru is a payload cache bounded by total payload bytes.
+type lru struct {
+	limit int
+
+	mu    sync.Mutex
+	size  int
+	order list.List // of *entry, most recently used first
+	items map[hash]*list.Element
+}
+
+type entry struct {
+	key  hash
+	data []byte
+}
+
+// get returns the payload cached under key and marks it recently used.
+func (c *lru) get(key hash) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry).data, true
+}
+
+// put caches data under key, evicting the least recently used payloads to stay
+// within the limit. Payloads larger than the limit are not cached.
+func (c *lru) put(key hash, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.items == nil {
+		c.items = make(map[hash]*list.Element)
+	}
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	if len(data) > c.limit {
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, data: data})
+	c.size += len(data)
+	for c.size > c.limit {
+		oldest := c.order.Back()
+		e := oldest.Value.(*entry)
+		c.order.Remove(oldest)
+		delete(c.items, e.key)
+		c.size -= len(e.data)
+	}
+}
@@ -0,0 +1,67 @@
+package enproto
+
+import "sync"
+
+// Budget is an Allocator that caps the payload bytes held at once across every reader
+// sharing it, so a spike of large frames on many connections cannot exhaust memory.
+// An Alloc that would take the total over the limit blocks until enough is freed,
+// which stalls the reader and in turn pushes back on its peer. A single payload larger
+// than the limit is admitted once nothing else is held.
+//
+// Payloads count against the budget until freed: call Release in OwnershipBorrow
+// mode, or Budget.Free in OwnershipCopy mode.
+type Budget struct {
+	inner Allocator
+	limit int
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	inUse int
+	peak  int
+}
+
+// NewBudget returns a Budget of limit bytes allocating from inner, or from
+// PoolAllocator if inner is nil. Pass it to WithAllocator on every reader it covers.
+func NewBudget(limit int, inner Allocator) *Budget {
+	if inner == nil {
+		inner = PoolAllocator()
+	}
+	b := &Budget{inner: inner, limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Alloc allocates n bytes, waiting until they fit in the budget.
+func (b *Budget) Alloc(n int) []byte {
+	b.mu.Lock()
+	for b.inUse > 0 && b.inUse+n > b.limit {
+		b.cond.Wait()
+	}
+	b.inUse += n
+	b.peak = max(b.peak, b.inUse)
+	b.mu.Unlock()
+	return b.inner.Alloc(n)
+}
+
+// Free returns buf to the inner allocator and its bytes to the budget.
+func (b *Budget) Free(buf []byte) {
+	b.inner.Free(buf)
+	b.mu.Lock()
+	b.inUse -= len(buf)
+	b.cond.Broadcast()
+	b.mu.Unlock()
+}
+
+// BudgetStats reports a Budget's usage.
+type BudgetStats struct {
+	Limit int
+	InUse int // bytes allocated and not yet freed
+	Peak  int // highest InUse seen
+}
+
+// Stats returns the Budget's current usage.
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BudgetStats{Limit: b.limit, InUse: b.inUse, Peak: b.peak}
+}
@@ -0,0 +1,84 @@
+package enproto
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestBudget_Backpressure checks that a reader blocks while the shared budget is
+// spent and resumes once another reader releases its payload.
+func TestBudget_Backpressure(t *testing.T) {
+	budget := NewBudget(100, nil)
+	newReader := func(n int) *FrameReader {
+		var buf bytes.Buffer
+		NewFrameWriter(&buf).WriteFrame(0x01, make([]byte, n))
+		return NewFrameReader(&buf, WithPayloadOwnership(OwnershipBorrow), WithAllocator(budget))
+	}
+	a, b := newReader(80), newReader(50)
+
+	_, held, err := a.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []byte)
+	go func() {
+		_, payload, err := b.ReadFrame()
+		if err != nil {
+			t.Error(err)
+		}
+		done <- payload
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second ReadFrame did not wait for the budget")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := a.Release(held); err != nil {
+		t.Fatal(err)
+	}
+	payload := <-done
+	if got := budget.Stats(); got.InUse != 50 || got.Peak != 80 {
+		t.Errorf("stats = %+v; want InUse 50, Peak 80", got)
+	}
+	b.Release(payload)
+	if got := budget.Stats().InUse; got != 0 {
+		t.Errorf("InUse after release = %d; want 0", got)
+	}
+}
+
+// TestBudget_Oversized checks that a payload larger than the whole budget is still
+// admitted when nothing else is held.
+func TestBudget_Oversized(t *testing.T) {
+	budget := NewBudget(10, &countingAllocator{})
+	buf := budget.Alloc(64)
+	if len(buf) != 64 {
+		t.Fatalf("len = %d; want 64", len(buf))
+	}
+	budget.Free(buf)
+	if got := budget.Stats(); got.InUse != 0 || got.Peak != 64 {
+		t.Errorf("stats = %+v; want InUse 0, Peak 64", got)
+	}
+}
+
+// TestBudget_TruncatedFrame checks that a payload whose read fails is freed back to
+// the budget in both ownership modes.
+func TestBudget_TruncatedFrame(t *testing.T) {
+	for _, mode := range []Ownership{OwnershipCopy, OwnershipBorrow} {
+		budget := NewBudget(100, nil)
+		var buf bytes.Buffer
+		NewFrameWriter(&buf).WriteFrame(0x01, make([]byte, 50))
+		buf.Truncate(buf.Len() - 10)
+
+		r := NewFrameReader(&buf, WithPayloadOwnership(mode), WithAllocator(budget))
+		if _, _, err := r.ReadFrame(); err == nil {
+			t.Fatalf("mode %v: reading a truncated frame succeeded", mode)
+		}
+		if s := budget.Stats(); s.InUse != 0 {
+			t.Errorf("mode %v: %d bytes still in use after a failed read", mode, s.InUse)
+		}
+	}
+}
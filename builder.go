@@ -0,0 +1,41 @@
+package enproto
+
+import "fmt"
+
+// FrameBuilder assembles a Frame step by step and validates it in Build.
+//
+//	frame, err := enproto.NewFrame(0x01).WithPayload(data).Build()
+type FrameBuilder struct {
+	frame   Frame
+	maxSize uint32 // 0 means maxAllowed
+}
+
+// NewFrame starts building a frame with the given message type.
+func NewFrame(msgType byte) *FrameBuilder {
+	return &FrameBuilder{frame: Frame{Type: msgType}}
+}
+
+// WithPayload sets the frame payload. The slice is not copied.
+func (b *FrameBuilder) WithPayload(payload []byte) *FrameBuilder {
+	b.frame.Payload = payload
+	return b
+}
+
+// WithMaxFrameSize sets the largest payload, in bytes, that Build accepts, to match
+// a writer configured with the option of the same name. The default is 100 MiB.
+func (b *FrameBuilder) WithMaxFrameSize(n uint32) *FrameBuilder {
+	b.maxSize = n
+	return b
+}
+
+// Build validates the frame against the protocol limits and returns it.
+func (b *FrameBuilder) Build() (Frame, error) {
+	limit := b.maxSize
+	if limit == 0 {
+		limit = maxAllowed
+	}
+	if uint64(len(b.frame.Payload)) > uint64(limit) {
+		return Frame{}, fmt.Errorf("%w: %d", ErrFrameTooLarge, len(b.frame.Payload))
+	}
+	return b.frame, nil
+}
@@ -0,0 +1,35 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestFrameBuilder_Build verifies a built frame carries the type and payload.
+func TestFrameBuilder_Build(t *testing.T) {
+	payload := []byte("built payload")
+
+	frame, err := NewFrame(0x7).WithPayload(payload).Build()
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+
+	if frame.Type != 0x7 {
+		t.Errorf("message type = %d; want %d", frame.Type, 0x7)
+	}
+	if !bytes.Equal(frame.Payload, payload) {
+		t.Errorf("payload = %q; want %q", frame.Payload, payload)
+	}
+}
+
+// TestFrameBuilder_Build_TooLarge ensures Build rejects payloads exceeding the size limit.
+func TestFrameBuilder_Build_TooLarge(t *testing.T) {
+	_, err := NewFrame(0x1).WithMaxFrameSize(16).WithPayload(make([]byte, 17)).Build()
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge, got %v", err)
+	}
+	if _, err := NewFrame(0x1).WithMaxFrameSize(16).WithPayload(make([]byte, 16)).Build(); err != nil {
+		t.Errorf("payload at the limit: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+	"github.com/ianchildress/enproto/enprototest"
+)
+
+// TestRoundTrip verifies a capture survives conversion to JSON lines and back.
+func TestRoundTrip(t *testing.T) {
+	wire, frames := enprototest.NewGenerator(3).Stream(10)
+
+	var jsonl bytes.Buffer
+	if err := ToJSONLines(&jsonl, bytes.NewReader(wire)); err != nil {
+		t.Fatalf("ToJSONLines error: %v", err)
+	}
+	if lines := strings.Count(jsonl.String(), "\n"); lines != len(frames) {
+		t.Errorf("wrote %d lines; want %d", lines, len(frames))
+	}
+
+	var back bytes.Buffer
+	if err := FromJSONLines(&back, &jsonl); err != nil {
+		t.Fatalf("FromJSONLines error: %v", err)
+	}
+	if !bytes.Equal(back.Bytes(), wire) {
+		t.Errorf("round-tripped capture differs from the original")
+	}
+}
+
+// TestFromJSONLines_Edited verifies hand-edited records get defaults and fresh lengths.
+func TestFromJSONLines_Edited(t *testing.T) {
+	edited := `{"header":{"type":7,"length":999},"payload":"aGVsbG8="}` + "\n"
+
+	var wire bytes.Buffer
+	if err := FromJSONLines(&wire, strings.NewReader(edited)); err != nil {
+		t.Fatalf("FromJSONLines error: %v", err)
+	}
+
+	msgType, payload, err := enproto.NewFrameReader(&wire).ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if msgType != 7 || string(payload) != "hello" {
+		t.Errorf("frame = %d %q; want %d %q", msgType, payload, 7, "hello")
+	}
+}
+
+// TestToJSONLines_Corrupt ensures a damaged capture reports which frame failed.
+func TestToJSONLines_Corrupt(t *testing.T) {
+	wire := append(enprototest.MustEncode(enproto.Frame{Type: 1}), 0xFF, 0xFF, 1, 1, 0, 0, 0, 0)
+
+	err := ToJSONLines(&bytes.Buffer{}, bytes.NewReader(wire))
+	if !errors.Is(err, enproto.ErrBadMagic) || !strings.Contains(err.Error(), "frame 1") {
+		t.Errorf("expected ErrBadMagic at frame 1, got %v", err)
+	}
+}
+
+// TestToJSONLines_FilterIgnored verifies a filter option cannot pair one frame's
+// header with another frame's payload: every frame is recorded as it was captured.
+func TestToJSONLines_FilterIgnored(t *testing.T) {
+	wire, frames := enprototest.NewGenerator(5).Stream(6)
+	skipOdd := enproto.WithFilter(func(h enproto.Header) enproto.Action {
+		if h.Type%2 == 1 {
+			return enproto.ActionSkip
+		}
+		return enproto.ActionDeliver
+	})
+
+	var jsonl bytes.Buffer
+	if err := ToJSONLines(&jsonl, bytes.NewReader(wire), skipOdd); err != nil {
+		t.Fatalf("ToJSONLines error: %v", err)
+	}
+	var back bytes.Buffer
+	if err := FromJSONLines(&back, &jsonl); err != nil {
+		t.Fatalf("FromJSONLines error: %v", err)
+	}
+	if !bytes.Equal(back.Bytes(), wire) {
+		t.Errorf("capture of %d frames did not survive a filtered conversion", len(frames))
+	}
+}
@@ -0,0 +1,53 @@
+// Command enproto-conformance runs the enprototest conformance suite against a peer
+// implementation listening on a TCP address.
+//
+// Usage:
+//
+//	enproto-conformance [-timeout 5s] host:port
+//
+// The peer must echo valid frames and close the connection on invalid ones; see
+// package enprototest. The exit status is 1 if any case fails.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ianchildress/enproto/enprototest"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 5*time.Second, "per-operation timeout")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: enproto-conformance [-timeout d] host:port")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	addr := flag.Arg(0)
+
+	dial := func() (net.Conn, error) {
+		return net.DialTimeout("tcp", addr, *timeout)
+	}
+
+	var failed int
+	for _, r := range enprototest.RunConformance(dial, *timeout) {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("FAIL  %s: %v\n", r.Name, r.Err)
+			continue
+		}
+		fmt.Printf("ok    %s\n", r.Name)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d case(s) failed\n", failed)
+		os.Exit(1)
+	}
+}
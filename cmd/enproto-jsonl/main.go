@@ -0,0 +1,34 @@
+// Command enproto-jsonl converts enproto captures to and from JSON lines.
+//
+// Usage:
+//
+//	enproto-jsonl < session.bin > session.jsonl
+//	enproto-jsonl -r < session.jsonl > session.bin
+//
+// A capture is the raw frame stream as it appeared on the wire; see package capture
+// for the JSON format.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ianchildress/enproto/capture"
+)
+
+func main() {
+	reverse := flag.Bool("r", false, "convert JSON lines back into a capture")
+	flag.Parse()
+
+	var err error
+	if *reverse {
+		err = capture.FromJSONLines(os.Stdout, os.Stdin)
+	} else {
+		err = capture.ToJSONLines(os.Stdout, os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "enproto-jsonl:", err)
+		os.Exit(1)
+	}
+}
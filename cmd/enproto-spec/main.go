@@ -0,0 +1,32 @@
+// Command enproto-spec exports the enproto wire specification and test vectors for
+// implementations in other languages.
+//
+// Usage:
+//
+//	enproto-spec [-out dir]
+//
+// It writes spec.json (the frame layout), vectors.json (encoded inputs with their
+// expected decoding) and one .bin file per vector holding its raw bytes.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ianchildress/enproto/enprototest"
+)
+
+func main() {
+	out := flag.String("out", ".", "output directory")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, "enproto-spec:", err)
+		os.Exit(1)
+	}
+	if err := enprototest.ExportSpec(*out); err != nil {
+		fmt.Fprintln(os.Stderr, "enproto-spec:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,99 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// spliceThreshold is the unbuffered payload remainder above which CopyFrames copies
+// straight between the transports rather than through the bufio buffers.
+const spliceThreshold = 64 * 1024
+
+// CopyFrames forwards frames from src to dst without decoding their payloads, until
+// src reaches a clean end of stream (returning nil) or reading or writing fails. It
+// returns the number of frames forwarded.
+//
+// Each header is validated against src's options and offered to filter, which may be
+// nil; skipped frames are dropped and rejected ones stop the copy with
+// ErrFrameRejected. Forwarded headers are written unchanged. Large payloads are
+// copied directly between the underlying transports, which lets the net package use
+// splice(2) between TCP connections on Linux. dst is flushed whenever src has no more
+// data buffered, and before CopyFrames returns unless writing failed.
+func CopyFrames(dst *FrameWriter, src *FrameReader, filter Filter) (int, error) {
+	var frames int
+	for {
+		h, err := src.nextHeader()
+		if errors.Is(err, io.EOF) {
+			return frames, dst.Flush()
+		}
+		if err != nil {
+			return frames, flushThen(dst, err)
+		}
+
+		if filter != nil {
+			if action := filter(h); action != ActionDeliver {
+				if err := src.discardPayload(h); err != nil {
+					return frames, flushThen(dst, err)
+				}
+				if action == ActionError {
+					return frames, flushThen(dst, fmt.Errorf("%w: type %d", ErrFrameRejected, h.Type))
+				}
+				continue
+			}
+		}
+
+		if err := copyFrame(dst, src, h); err != nil {
+			return frames, err
+		}
+		frames++
+
+		if src.br.Buffered() == 0 {
+			if err := dst.Flush(); err != nil {
+				return frames, err
+			}
+		}
+	}
+}
+
+// flushThen flushes dst and returns err, or the flush error if there was one.
+func flushThen(dst *FrameWriter, err error) error {
+	if ferr := dst.Flush(); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+// copyFrame writes h to dst and copies its payload across from src.
+func copyFrame(dst *FrameWriter, src *FrameReader, h Header) error {
+	var header [HeaderSize]byte
+	if _, err := dst.bw.Write(AppendHeader(header[:0], h)); err != nil {
+		return err
+	}
+
+	dst.cfg.counters.wrote(uint64(h.Length))
+	return src.budgeted(int(h.Length), func() error {
+		length := int64(h.Length)
+
+		// Whatever src already buffered goes through the buffers.
+		n, err := io.CopyN(dst.bw, src.br, min(length, int64(src.br.Buffered())))
+		if err != nil {
+			return err
+		}
+		rest := length - n
+
+		if rest > spliceThreshold {
+			// src has nothing buffered now, so read the transport directly.
+			if err := dst.Flush(); err != nil {
+				return err
+			}
+			_, err = io.CopyN(dst.w, src.src, rest)
+		} else {
+			_, err = io.CopyN(dst.bw, src.br, rest)
+		}
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	})
+}
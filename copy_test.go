@@ -0,0 +1,76 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestCopyFrames verifies frames are forwarded intact, both through the buffers and
+// directly between transports, and that the filter applies.
+func TestCopyFrames(t *testing.T) {
+	big := bytes.Repeat([]byte("0123456789"), 20000) // well past spliceThreshold
+	want := []Frame{
+		{Type: 0x01, Payload: []byte("small")},
+		{Type: 0x02, Payload: big},
+		{Type: 0x03, Payload: []byte{}},
+	}
+
+	var in bytes.Buffer
+	fw := NewFrameWriter(&in)
+	for _, f := range want {
+		fw.WriteFrame(f.Type, f.Payload)
+	}
+	fw.WriteFrame(0x7F, []byte("dropped"))
+
+	var out bytes.Buffer
+	src := NewFrameReader(&in, WithReadBufferSize(1024))
+	n, err := CopyFrames(NewFrameWriter(&out), src, func(h Header) Action {
+		if h.Type == 0x7F {
+			return ActionSkip
+		}
+		return ActionDeliver
+	})
+	if err != nil {
+		t.Fatalf("CopyFrames: %v", err)
+	}
+	if n != len(want) {
+		t.Errorf("forwarded %d frames; want %d", n, len(want))
+	}
+
+	fr := NewFrameReader(&out)
+	for i, f := range want {
+		msgType, p, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("frame %d: ReadFrame: %v", i, err)
+		}
+		if msgType != f.Type || !bytes.Equal(p, f.Payload) {
+			t.Errorf("frame %d: type %d, %d bytes; want type %d, %d bytes", i, msgType, len(p), f.Type, len(f.Payload))
+		}
+	}
+	if _, err := fr.SkipFrame(); err == nil {
+		t.Errorf("found a frame the filter should have dropped")
+	}
+}
+
+// TestCopyFrames_Reject verifies a rejected frame stops the copy.
+func TestCopyFrames_Reject(t *testing.T) {
+	var in bytes.Buffer
+	fw := NewFrameWriter(&in)
+	fw.WriteFrame(0x01, []byte("ok"))
+	fw.WriteFrame(0x02, []byte("bad"))
+
+	var out bytes.Buffer
+	n, err := CopyFrames(NewFrameWriter(&out), NewFrameReader(&in), func(h Header) Action {
+		if h.Type == 0x02 {
+			return ActionError
+		}
+		return ActionDeliver
+	})
+	if !errors.Is(err, ErrFrameRejected) {
+		t.Errorf("CopyFrames error = %v; want %v", err, ErrFrameRejected)
+	}
+	if n != 1 {
+		t.Errorf("forwarded %d frames; want 1", n)
+	}
+}
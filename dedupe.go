@@ -0,0 +1,62 @@
+package enproto
+
+import (
+	"crypto/sha256"
+	"sync/atomic"
+	"time"
+
+	"github.com/ianchildress/enproto/internal/seen"
+)
+
+// DedupeKey identifies frames for duplicate suppression. Frames with equal keys are
+// duplicates.
+type DedupeKey func(msgType byte, payload []byte) string
+
+// PayloadHashKey is the default DedupeKey: the message type and a SHA-256 hash of
+// the payload. Use a key that extracts an application message ID instead where
+// identical payloads can legitimately repeat.
+func PayloadHashKey(msgType byte, payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return string(append([]byte{msgType}, sum[:]...))
+}
+
+// Deduper is a Handler that passes frames on to another Handler, dropping any frame
+// whose key was already seen within a time window. Put it in front of handlers fed by
+// relays or peers that may resend frames. It is safe for concurrent use.
+type Deduper struct {
+	h    Handler
+	key  DedupeKey
+	now  func() time.Time // for tests
+	seen seen.Set[string]
+
+	dropped atomic.Uint64
+}
+
+// NewDeduper returns a Deduper in front of h that suppresses duplicates seen within
+// window. A nil key means PayloadHashKey.
+func NewDeduper(h Handler, window time.Duration, key DedupeKey) *Deduper {
+	if key == nil {
+		key = PayloadHashKey
+	}
+	return &Deduper{
+		h:    h,
+		key:  key,
+		now:  time.Now,
+		seen: seen.Set[string]{TTL: window},
+	}
+}
+
+// ServeFrame passes the frame to the wrapped Handler unless it is a duplicate, which
+// is dropped with a nil error.
+func (d *Deduper) ServeFrame(msgType byte, payload []byte) error {
+	if !d.seen.Add(d.key(msgType, payload), d.now()) {
+		d.dropped.Add(1)
+		return nil
+	}
+	return d.h.ServeFrame(msgType, payload)
+}
+
+// Dropped returns how many duplicates have been suppressed.
+func (d *Deduper) Dropped() uint64 {
+	return d.dropped.Load()
+}
@@ -0,0 +1,56 @@
+package enproto
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDeduper verifies duplicates are dropped inside the window and delivered again
+// once it has passed.
+func TestDeduper(t *testing.T) {
+	var delivered []string
+	h := HandlerFunc(func(_ byte, p []byte) error {
+		delivered = append(delivered, string(p))
+		return nil
+	})
+
+	clock := time.Unix(0, 0)
+	d := NewDeduper(h, time.Minute, nil)
+	d.now = func() time.Time { return clock }
+
+	d.ServeFrame(0x01, []byte("a"))
+	d.ServeFrame(0x01, []byte("a")) // duplicate
+	d.ServeFrame(0x02, []byte("a")) // same payload, different type
+	d.ServeFrame(0x01, []byte("b"))
+
+	clock = clock.Add(2 * time.Minute)
+	d.ServeFrame(0x01, []byte("a")) // window passed
+
+	want := []string{"a", "a", "b", "a"}
+	if len(delivered) != len(want) {
+		t.Fatalf("delivered %q; want %q", delivered, want)
+	}
+	for i := range want {
+		if delivered[i] != want[i] {
+			t.Errorf("delivered[%d] = %q; want %q", i, delivered[i], want[i])
+		}
+	}
+	if d.Dropped() != 1 {
+		t.Errorf("Dropped = %d; want 1", d.Dropped())
+	}
+}
+
+// TestDeduper_Key verifies a custom key, such as a message ID prefix, is used.
+func TestDeduper_Key(t *testing.T) {
+	var n int
+	h := HandlerFunc(func(byte, []byte) error { n++; return nil })
+	d := NewDeduper(h, time.Minute, func(_ byte, p []byte) string { return string(p[:2]) })
+
+	d.ServeFrame(0x01, []byte("01first"))
+	d.ServeFrame(0x01, []byte("01resent with other bytes"))
+	d.ServeFrame(0x01, []byte("02second"))
+
+	if n != 2 {
+		t.Errorf("delivered %d frames; want 2", n)
+	}
+}
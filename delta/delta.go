@@ -0,0 +1,203 @@
+// Package delta sends successive frames of selected message types as binary diffs
+// against the previous frame of the same type, for state snapshots that change
+// little between updates.
+//
+// A delta is sent as a DELTA frame: [1B message type][uvarint new length] followed by
+// ops of [uvarint keep][uvarint literal length][literal bytes]. Each op keeps that
+// many bytes from the previous payload at the current offset, then appends the
+// literal bytes. Frames are sent whole when there is no previous payload or the diff
+// would not be smaller. The DELTA type is reserved on connections that use deltas.
+//
+// Nothing is negotiated on the wire: both ends must be configured with the same
+// delta types, and the stream must be ordered and lossless.
+package delta
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TypeDelta is the control frame type carrying diffs.
+const TypeDelta byte = 0xEC
+
+// minMatch is the shortest run of unchanged bytes worth an op of its own; shorter
+// runs are folded into the surrounding literal.
+const minMatch = 8
+
+var (
+	ErrMalformed = errors.New("delta: malformed delta frame")
+	// ErrNoBase is returned for a delta that arrives before any frame of its type.
+	ErrNoBase = errors.New("delta: no previous frame to apply delta to")
+)
+
+// Writer writes frames, diffing those of its delta types against the previous frame
+// of the same type. It is safe for concurrent use.
+type Writer struct {
+	mu    sync.Mutex
+	fw    *enproto.FrameWriter
+	types [256]bool
+	prev  [256][]byte
+}
+
+// NewWriter returns a Writer to fw that sends frames of the given types as deltas.
+func NewWriter(fw *enproto.FrameWriter, types ...byte) *Writer {
+	w := &Writer{fw: fw}
+	for _, t := range types {
+		w.types[t] = true
+	}
+	return w
+}
+
+// WriteFrame writes a frame and flushes, as a delta where that is smaller. The
+// payload is copied, so the caller may reuse it.
+func (w *Writer) WriteFrame(msgType byte, payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.types[msgType] {
+		return w.fw.WriteFrame(msgType, payload)
+	}
+
+	prev := w.prev[msgType]
+	w.prev[msgType] = append(prev[:0:0], payload...)
+
+	if prev != nil {
+		d := encode([]byte{msgType}, prev, payload)
+		if len(d) < len(payload) {
+			return w.fw.WriteFrame(TypeDelta, d)
+		}
+	}
+	return w.fw.WriteFrame(msgType, payload)
+}
+
+// Reader is a Handler that reconstructs frames sent by a Writer and passes them on.
+// It must see every frame of the connection in order.
+type Reader struct {
+	h     enproto.Handler
+	types [256]bool
+
+	mu   sync.Mutex
+	prev [256][]byte
+}
+
+// NewReader returns a Reader handing reconstructed frames to h, for a Writer
+// configured with the same types.
+func NewReader(h enproto.Handler, types ...byte) *Reader {
+	r := &Reader{h: h}
+	for _, t := range types {
+		r.types[t] = true
+	}
+	return r
+}
+
+// ServeFrame applies DELTA frames to the previous payload of their type and passes
+// every resulting frame, and every other frame, to the wrapped Handler.
+func (r *Reader) ServeFrame(msgType byte, payload []byte) error {
+	if msgType != TypeDelta {
+		if r.types[msgType] {
+			r.mu.Lock()
+			r.prev[msgType] = append(r.prev[msgType][:0:0], payload...)
+			r.mu.Unlock()
+		}
+		return r.h.ServeFrame(msgType, payload)
+	}
+
+	if len(payload) < 1 {
+		return ErrMalformed
+	}
+	msgType = payload[0]
+	if !r.types[msgType] {
+		return fmt.Errorf("%w: type %d is not a delta type", ErrMalformed, msgType)
+	}
+
+	r.mu.Lock()
+	prev := r.prev[msgType]
+	if prev == nil {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: type %d", ErrNoBase, msgType)
+	}
+	out, err := decode(prev, payload[1:])
+	if err == nil {
+		r.prev[msgType] = out
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// out is kept as the next base, so hand the handler its own copy.
+	return r.h.ServeFrame(msgType, append([]byte(nil), out...))
+}
+
+// encode appends the diff from prev to next to dst.
+func encode(dst, prev, next []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(next)))
+
+	matches := func(i int) int {
+		n := 0
+		for i+n < len(next) && i+n < len(prev) && next[i+n] == prev[i+n] {
+			n++
+		}
+		return n
+	}
+
+	for i := 0; i < len(next); {
+		keep := matches(i)
+		i += keep
+
+		lit := i
+		for i < len(next) {
+			if m := matches(i); m >= minMatch || i+m == len(next) && m > 0 {
+				break
+			}
+			i++
+		}
+
+		dst = binary.AppendUvarint(dst, uint64(keep))
+		dst = binary.AppendUvarint(dst, uint64(i-lit))
+		dst = append(dst, next[lit:i]...)
+	}
+	return dst
+}
+
+// decode applies the diff d to prev.
+func decode(prev, d []byte) ([]byte, error) {
+	n, k := binary.Uvarint(d)
+	// Every output byte is kept from prev or carried as a literal.
+	if k <= 0 || n > uint64(len(prev)+len(d)) {
+		return nil, ErrMalformed
+	}
+	d = d[k:]
+	out := make([]byte, 0, n)
+
+	for uint64(len(out)) < n {
+		keep, k := binary.Uvarint(d)
+		if k <= 0 {
+			return nil, ErrMalformed
+		}
+		d = d[k:]
+		lit, k := binary.Uvarint(d)
+		if k <= 0 {
+			return nil, ErrMalformed
+		}
+		d = d[k:]
+
+		pos := uint64(len(out))
+		if keep == 0 && lit == 0 ||
+			keep > uint64(len(prev)) || pos+keep > uint64(len(prev)) ||
+			lit > uint64(len(d)) || pos+keep+lit > n {
+			return nil, ErrMalformed
+		}
+		out = append(out, prev[pos:pos+keep]...)
+		out = append(out, d[:lit]...)
+		d = d[lit:]
+	}
+	if len(d) != 0 {
+		return nil, ErrMalformed
+	}
+	return out, nil
+}
@@ -0,0 +1,119 @@
+package delta
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestEncodeDecode verifies diffs round-trip for edits, growth and shrinkage.
+func TestEncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	base := make([]byte, 4096)
+	rng.Read(base)
+
+	edited := append([]byte(nil), base...)
+	for i := 0; i < 20; i++ {
+		edited[rng.Intn(len(edited))] ^= 0xFF
+	}
+
+	tests := []struct {
+		name       string
+		prev, next []byte
+	}{
+		{"edits", base, edited},
+		{"grow", base, append(append([]byte(nil), base...), "tail"...)},
+		{"shrink", base, base[:1000]},
+		{"empty", base, nil},
+		{"from empty", nil, base[:50]},
+		{"identical", base, base},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := encode(nil, tt.prev, tt.next)
+			got, err := decode(tt.prev, d)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !bytes.Equal(got, tt.next) {
+				t.Errorf("decoded %d bytes differing from the %d encoded", len(got), len(tt.next))
+			}
+		})
+	}
+
+	if d := encode(nil, base, edited); len(d) > len(edited)/10 {
+		t.Errorf("diff of 20 edits is %d bytes; want well under %d", len(d), len(edited)/10)
+	}
+}
+
+// TestDecode_Malformed ensures corrupt diffs are rejected rather than misapplied.
+func TestDecode_Malformed(t *testing.T) {
+	prev := []byte("0123456789")
+	for _, d := range [][]byte{
+		{},                  // no length
+		{5, 0, 0},           // empty op
+		{5, 20, 0},          // keep past prev
+		{3, 0, 5, 'a', 'b'}, // literal past the data
+		{2, 2, 0, 9},        // trailing bytes
+		{200, 1},            // length beyond what the ops could produce
+	} {
+		if _, err := decode(prev, d); !errors.Is(err, ErrMalformed) {
+			t.Errorf("decode(%v) error = %v; want %v", d, err, ErrMalformed)
+		}
+	}
+}
+
+// TestWriterReader verifies snapshots arrive intact and later ones travel as deltas.
+func TestWriterReader(t *testing.T) {
+	var wire bytes.Buffer
+	w := NewWriter(enproto.NewFrameWriter(&wire), 0x10)
+
+	snap := bytes.Repeat([]byte("state-"), 500)
+	w.WriteFrame(0x10, snap)
+	fullSize := wire.Len()
+
+	snap[100] = 'X'
+	w.WriteFrame(0x10, snap)
+	w.WriteFrame(0x11, []byte("other"))
+	if deltaSize := wire.Len() - fullSize; deltaSize > fullSize/10 {
+		t.Errorf("second snapshot cost %d bytes; want a small delta", deltaSize)
+	}
+
+	var got []enproto.Frame
+	r := NewReader(enproto.HandlerFunc(func(t byte, p []byte) error {
+		got = append(got, enproto.Frame{Type: t, Payload: p})
+		return nil
+	}), 0x10)
+
+	fr := enproto.NewFrameReader(&wire)
+	for {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			break
+		}
+		if err := r.ServeFrame(msgType, payload); err != nil {
+			t.Fatalf("ServeFrame: %v", err)
+		}
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("got %d frames; want 3", len(got))
+	}
+	if got[1].Type != 0x10 || !bytes.Equal(got[1].Payload, snap) {
+		t.Errorf("reconstructed snapshot differs from the one sent")
+	}
+	if got[2].Type != 0x11 || string(got[2].Payload) != "other" {
+		t.Errorf("third frame = %v; want type 0x11 %q", got[2], "other")
+	}
+}
+
+// TestReader_NoBase verifies a delta without a previous frame fails.
+func TestReader_NoBase(t *testing.T) {
+	r := NewReader(enproto.HandlerFunc(func(byte, []byte) error { return nil }), 0x10)
+	if err := r.ServeFrame(TypeDelta, []byte{0x10, 1, 0, 1, 'a'}); !errors.Is(err, ErrNoBase) {
+		t.Errorf("ServeFrame error = %v; want %v", err, ErrNoBase)
+	}
+}
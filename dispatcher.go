@@ -0,0 +1,270 @@
+package enproto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dispatcher serves frames from a FrameReader like Router.Serve, but runs handlers
+// concurrently on up to a fixed number of goroutines rather than one after another.
+// Individual message types can be capped below that limit with SetTypeLimit, or
+// declared ordering-sensitive with SetTypePolicy.
+//
+// Frames are read ahead into a bounded queue and started in the order they were
+// read. A frame waiting for a free slot, including one held back by its type's cap,
+// holds up the frames queued behind it.
+type Dispatcher struct {
+	h         Handler
+	workers   int
+	queueSize int
+
+	limits [256]int // per-type caps; 0 means only the worker limit applies
+
+	queued  atomic.Int64
+	running atomic.Int64
+	handled atomic.Uint64
+
+	latency [256]atomic.Pointer[latencyRecorder] // allocated on a type's first frame
+}
+
+// NewDispatcher returns a Dispatcher that hands frames to h, typically a Router,
+// running at most workers handlers at once and reading at most queueSize frames
+// ahead of them. Values below 1 are treated as 1.
+func NewDispatcher(h Handler, workers, queueSize int) *Dispatcher {
+	return &Dispatcher{
+		h:         h,
+		workers:   max(workers, 1),
+		queueSize: max(queueSize, 1),
+	}
+}
+
+// SetTypeLimit caps how many handlers for msgType may run at once. A limit of 0
+// removes the cap. It must be called before Serve.
+func (d *Dispatcher) SetTypeLimit(msgType byte, n int) {
+	d.limits[msgType] = max(n, 0)
+}
+
+// DispatchPolicy declares whether frames of one message type may be handled in parallel.
+type DispatchPolicy int
+
+const (
+	// DispatchConcurrent lets handlers for the type run in parallel, up to the worker
+	// limit. It suits idempotent or order-independent frames and is the default.
+	DispatchConcurrent DispatchPolicy = iota
+	// DispatchSerialized runs handlers for the type one at a time, in the order the
+	// frames were read. Frames of other types still run alongside them.
+	DispatchSerialized
+)
+
+// SetTypePolicy sets how frames of msgType are dispatched. DispatchSerialized is a
+// type limit of 1, and DispatchConcurrent removes any type limit. It must be called
+// before Serve.
+func (d *Dispatcher) SetTypePolicy(msgType byte, p DispatchPolicy) {
+	if p == DispatchSerialized {
+		d.SetTypeLimit(msgType, 1)
+		return
+	}
+	d.SetTypeLimit(msgType, 0)
+}
+
+// DispatchStats is a snapshot of a Dispatcher's load.
+type DispatchStats struct {
+	Queued  int    // frames read and waiting for a worker
+	Running int    // handlers currently running
+	Handled uint64 // handlers that have returned
+}
+
+// Stats returns the Dispatcher's current load, summed over all its Serve calls.
+func (d *Dispatcher) Stats() DispatchStats {
+	return DispatchStats{
+		Queued:  int(d.queued.Load()),
+		Running: int(d.running.Load()),
+		Handled: d.handled.Load(),
+	}
+}
+
+// Latency returns the distribution of handler run times for msgType, summed over all
+// Serve calls.
+func (d *Dispatcher) Latency(msgType byte) LatencyHistogram {
+	if r := d.latency[msgType].Load(); r != nil {
+		return r.snapshot()
+	}
+	return LatencyHistogram{}
+}
+
+func (d *Dispatcher) recordLatency(msgType byte, took time.Duration) {
+	r := d.latency[msgType].Load()
+	if r == nil {
+		d.latency[msgType].CompareAndSwap(nil, new(latencyRecorder))
+		r = d.latency[msgType].Load()
+	}
+	r.record(took)
+}
+
+// typeLabels holds the pprof labels handlers run under, one set per message type.
+var typeLabels = func() (l [256]pprof.LabelSet) {
+	for t := range l {
+		l[t] = pprof.Labels("msg_type", fmt.Sprintf("%#02x", t))
+	}
+	return l
+}()
+
+// Serve reads frames from r and dispatches them until reading or a handler fails.
+// A clean end of stream returns nil once every started handler has returned. After a
+// handler error no further frames are started, and Serve returns that error when the
+// read in progress completes. Payloads are released back to r once their handler
+// returns, so in OwnershipBorrow mode handlers must not retain them.
+//
+// Handlers run with a pprof label msg_type set to their frame's type, such as
+// "0x10", so CPU profiles can attribute time to message types. Goroutines a
+// handler starts inherit the label.
+func (d *Dispatcher) Serve(r *FrameReader) error {
+	s := &dispatch{
+		d:      d,
+		r:      r,
+		sem:    make(chan struct{}, d.workers),
+		failed: make(chan struct{}),
+	}
+	for t, n := range d.limits {
+		if n > 0 {
+			s.typeSem[t] = make(chan struct{}, n)
+		}
+	}
+
+	queue := make(chan Frame, d.queueSize)
+	scheduled := make(chan struct{})
+	go func() {
+		defer close(scheduled)
+		s.schedule(queue)
+	}()
+
+	readErr := s.read(queue)
+	close(queue)
+	<-scheduled
+	s.wg.Wait()
+
+	if err := s.err(); err != nil {
+		return err
+	}
+	if errors.Is(readErr, io.EOF) {
+		return nil
+	}
+	return readErr
+}
+
+// dispatch is the state of one Dispatcher.Serve call.
+type dispatch struct {
+	d       *Dispatcher
+	r       *FrameReader
+	sem     chan struct{}
+	typeSem [256]chan struct{}
+	wg      sync.WaitGroup
+
+	once    sync.Once
+	failed  chan struct{} // closed on the first handler error
+	failure error
+}
+
+// read queues frames until reading fails or a handler has failed.
+func (s *dispatch) read(queue chan<- Frame) error {
+	for {
+		msgType, payload, err := s.r.ReadFrame()
+		if err != nil {
+			return err
+		}
+
+		s.d.queued.Add(1)
+		select {
+		case queue <- Frame{Type: msgType, Payload: payload}:
+		case <-s.failed:
+			s.d.queued.Add(-1)
+			s.r.Release(payload)
+			return nil
+		}
+	}
+}
+
+// schedule starts a handler for each queued frame once its type and the pool have
+// room, and drops the remaining frames after a failure.
+func (s *dispatch) schedule(queue <-chan Frame) {
+	for f := range queue {
+		typeSem := s.typeSem[f.Type]
+		ok := s.acquire(typeSem)
+		if ok && !s.acquire(s.sem) {
+			s.release(typeSem)
+			ok = false
+		}
+		if !ok {
+			s.d.queued.Add(-1)
+			s.r.Release(f.Payload)
+			continue
+		}
+
+		s.d.queued.Add(-1)
+		s.d.running.Add(1)
+		s.wg.Add(1)
+		go s.run(f, typeSem)
+	}
+}
+
+// acquire takes a slot in sem, which may be nil for no limit. It gives up and
+// returns false once a handler has failed.
+func (s *dispatch) acquire(sem chan struct{}) bool {
+	if sem == nil {
+		return true
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-s.failed:
+		return false
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s *dispatch) release(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+}
+
+// run handles one frame and frees its slots.
+func (s *dispatch) run(f Frame, typeSem chan struct{}) {
+	defer s.wg.Done()
+
+	var err error
+	start := time.Now()
+	pprof.Do(context.Background(), typeLabels[f.Type], func(context.Context) {
+		err = s.d.h.ServeFrame(f.Type, f.Payload)
+	})
+	s.d.recordLatency(f.Type, time.Since(start))
+	s.r.Release(f.Payload)
+
+	s.d.running.Add(-1)
+	s.d.handled.Add(1)
+	s.release(s.sem)
+	s.release(typeSem)
+
+	if err != nil {
+		s.once.Do(func() {
+			s.failure = err
+			close(s.failed)
+		})
+	}
+}
+
+// err returns the first handler error, if any.
+func (s *dispatch) err() error {
+	select {
+	case <-s.failed:
+		return s.failure
+	default:
+		return nil
+	}
+}
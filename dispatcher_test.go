@@ -0,0 +1,212 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeFrames encodes n frames of msgType into a buffer for Dispatcher tests.
+func writeFrames(t *testing.T, buf *bytes.Buffer, msgType byte, n int) {
+	t.Helper()
+	fw := NewFrameWriter(buf)
+	for i := 0; i < n; i++ {
+		if err := fw.WriteFrame(msgType, []byte{byte(i)}); err != nil {
+			t.Fatalf("WriteFrame: %v", err)
+		}
+	}
+}
+
+// peakCounter tracks the highest number of concurrent handler calls.
+type peakCounter struct {
+	cur, peak atomic.Int32
+}
+
+func (p *peakCounter) enter() {
+	n := p.cur.Add(1)
+	for {
+		old := p.peak.Load()
+		if n <= old || p.peak.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+func (p *peakCounter) leave() { p.cur.Add(-1) }
+
+// TestDispatcher_Concurrency verifies handlers run in parallel up to the worker limit.
+func TestDispatcher_Concurrency(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrames(t, &buf, 0x01, 20)
+
+	var pc peakCounter
+	d := NewDispatcher(HandlerFunc(func(byte, []byte) error {
+		pc.enter()
+		defer pc.leave()
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}), 4, 8)
+
+	if err := d.Serve(NewFrameReader(&buf)); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if peak := pc.peak.Load(); peak < 2 || peak > 4 {
+		t.Errorf("peak concurrency = %d; want between 2 and 4", peak)
+	}
+	if s := d.Stats(); s.Handled != 20 || s.Running != 0 || s.Queued != 0 {
+		t.Errorf("Stats = %+v; want 20 handled, none running or queued", s)
+	}
+}
+
+// TestDispatcher_TypeLimit verifies a per-type cap holds below the worker limit.
+func TestDispatcher_TypeLimit(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrames(t, &buf, 0x02, 10)
+
+	var pc peakCounter
+	d := NewDispatcher(HandlerFunc(func(byte, []byte) error {
+		pc.enter()
+		defer pc.leave()
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}), 8, 8)
+	d.SetTypeLimit(0x02, 1)
+
+	if err := d.Serve(NewFrameReader(&buf)); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if peak := pc.peak.Load(); peak != 1 {
+		t.Errorf("peak concurrency for capped type = %d; want 1", peak)
+	}
+}
+
+// TestDispatcher_HandlerError verifies Serve reports the first handler error and
+// stops starting new frames.
+func TestDispatcher_HandlerError(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrames(t, &buf, 0x03, 50)
+
+	errBoom := errors.New("boom")
+	var mu sync.Mutex
+	var calls int
+	d := NewDispatcher(HandlerFunc(func(_ byte, p []byte) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if p[0] == 0 {
+			return errBoom
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	}), 1, 1)
+
+	if err := d.Serve(NewFrameReader(&buf)); !errors.Is(err, errBoom) {
+		t.Fatalf("Serve error = %v; want %v", err, errBoom)
+	}
+	if calls >= 50 {
+		t.Errorf("handled %d frames after a failure; want fewer than 50", calls)
+	}
+}
+
+// TestDispatcher_Borrow verifies borrowed payloads are released after their handler.
+func TestDispatcher_Borrow(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrames(t, &buf, 0x04, 10)
+
+	alloc := &countingAllocator{}
+	fr := NewFrameReader(&buf, WithPayloadOwnership(OwnershipBorrow), WithAllocator(alloc))
+	d := NewDispatcher(HandlerFunc(func(byte, []byte) error { return nil }), 3, 3)
+	if err := d.Serve(fr); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if alloc.allocs != 10 || alloc.frees != 10 {
+		t.Errorf("allocs, frees = %d, %d; want 10, 10", alloc.allocs, alloc.frees)
+	}
+}
+
+// TestDispatcher_SerializedOrder verifies a serialized type is handled in read order
+// while a concurrent type runs alongside it.
+func TestDispatcher_SerializedOrder(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	for i := 0; i < 30; i++ {
+		fw.WriteFrame(0x05, []byte{byte(i)})
+		fw.WriteFrame(0x06, []byte{byte(i)})
+	}
+
+	var mu sync.Mutex
+	var order []byte
+	d := NewDispatcher(HandlerFunc(func(msgType byte, p []byte) error {
+		if msgType == 0x05 {
+			time.Sleep(time.Duration(30-int(p[0])) * 50 * time.Microsecond) // earlier frames are slower
+			mu.Lock()
+			order = append(order, p[0])
+			mu.Unlock()
+		}
+		return nil
+	}), 8, 16)
+	d.SetTypePolicy(0x05, DispatchSerialized)
+
+	if err := d.Serve(NewFrameReader(&buf)); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	for i, v := range order {
+		if int(v) != i {
+			t.Fatalf("serialized frames handled out of order: %v", order)
+		}
+	}
+	if len(order) != 30 {
+		t.Errorf("handled %d serialized frames; want 30", len(order))
+	}
+}
+
+// TestDispatcher_Latency verifies handler run times are recorded per type.
+func TestDispatcher_Latency(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrames(t, &buf, 0x01, 3)
+	writeFrames(t, &buf, 0x02, 1)
+
+	d := NewDispatcher(HandlerFunc(func(msgType byte, _ []byte) error {
+		if msgType == 0x02 {
+			time.Sleep(2 * time.Millisecond)
+		}
+		return nil
+	}), 2, 4)
+	if err := d.Serve(NewFrameReader(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	if h := d.Latency(0x01); h.Count != 3 {
+		t.Errorf("type 1 count = %d; want 3", h.Count)
+	}
+	if h := d.Latency(0x02); h.Count != 1 || h.Sum < 2*time.Millisecond {
+		t.Errorf("type 2 = %d samples totalling %v; want 1 of at least 2ms", h.Count, h.Sum)
+	}
+	if h := d.Latency(0x03); h.Count != 0 {
+		t.Errorf("type 3 count = %d; want 0", h.Count)
+	}
+}
+
+// TestDispatcher_ProfileLabels verifies handlers run under their message type's
+// pprof label.
+func TestDispatcher_ProfileLabels(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrames(t, &buf, 0x2A, 1)
+
+	var profile bytes.Buffer
+	d := NewDispatcher(HandlerFunc(func(byte, []byte) error {
+		return pprof.Lookup("goroutine").WriteTo(&profile, 1)
+	}), 1, 1)
+	if err := d.Serve(NewFrameReader(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `"msg_type":"0x2a"`; !strings.Contains(profile.String(), want) {
+		t.Errorf("goroutine profile has no %s label", want)
+	}
+}
@@ -0,0 +1,130 @@
+// Package dump writes the frames a connection receives to a log while dumping is
+// switched on, and lets a trusted peer switch it on and off with DEBUG frames, so a
+// live connection can be inspected without a restart.
+//
+// A DEBUG frame is [1B flags][8B sequence], big-endian. Flag bit 0 turns dumping on;
+// a clear bit turns it off. Sequence numbers must increase from one DEBUG frame to
+// the next, so a recorded frame cannot be replayed to undo a later one.
+//
+// DEBUG frames carry no authentication of their own. Serve them through a
+// sign.Verifier that requires them to be signed by an operator key, so only the key's
+// holder can turn dumping on:
+//
+//	d := dump.New(rt, os.Stderr)
+//	h := sign.NewVerifier(d, sign.KeyVerifier(operatorKey), sign.RequireSigned(dump.TypeDebug))
+//
+// The DEBUG type is reserved on connections that use remote dumping.
+package dump
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TypeDebug is the frame type that switches dumping on or off.
+const TypeDebug byte = 0xB8
+
+const flagDump byte = 1 << 0
+
+var (
+	ErrMalformed = errors.New("dump: malformed DEBUG frame")
+
+	// ErrReplayed is returned for a DEBUG frame whose sequence number is not above
+	// that of the last one accepted.
+	ErrReplayed = errors.New("dump: DEBUG frame sequence number not increasing")
+)
+
+// Toggle returns the payload of a DEBUG frame that turns dumping on or off. seq must
+// be higher than in any DEBUG frame sent on the connection before; a timestamp will do.
+func Toggle(on bool, seq uint64) []byte {
+	var p [9]byte
+	if on {
+		p[0] = flagDump
+	}
+	binary.BigEndian.PutUint64(p[1:], seq)
+	return p[:]
+}
+
+// Option configures a Dumper.
+type Option func(*Dumper)
+
+// WithFormatter sets how dumped frames are rendered. The default is the zero
+// enproto.Formatter.
+func WithFormatter(fm enproto.Formatter) Option {
+	return func(d *Dumper) {
+		d.fm = fm
+	}
+}
+
+// Dumper passes frames on to a Handler, writing each to a log first while dumping is
+// on. Dumping starts off. It is safe for concurrent use.
+type Dumper struct {
+	next enproto.Handler
+	fm   enproto.Formatter
+
+	mu      sync.Mutex
+	w       io.Writer
+	on      bool
+	seq     uint64
+	started bool // a DEBUG frame has been accepted, so seq is set
+}
+
+// New returns a Dumper passing frames to next and dumping them to w, one line each.
+func New(next enproto.Handler, w io.Writer, opts ...Option) *Dumper {
+	d := &Dumper{next: next, w: w}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// SetEnabled turns dumping on or off locally, for an admin endpoint of the process
+// itself.
+func (d *Dumper) SetEnabled(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.on = on
+}
+
+// Enabled reports whether frames are being dumped.
+func (d *Dumper) Enabled() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.on
+}
+
+// ServeFrame applies DEBUG frames and passes every other frame on, dumping it first
+// if dumping is on. A failed write to the log does not stop the frame.
+func (d *Dumper) ServeFrame(msgType byte, payload []byte) error {
+	if msgType == TypeDebug {
+		return d.toggle(payload)
+	}
+
+	d.mu.Lock()
+	if d.on {
+		io.WriteString(d.w, d.fm.Format(enproto.Frame{Type: msgType, Payload: payload})+"\n")
+	}
+	d.mu.Unlock()
+
+	return d.next.ServeFrame(msgType, payload)
+}
+
+func (d *Dumper) toggle(payload []byte) error {
+	if len(payload) != 9 || payload[0]&^flagDump != 0 {
+		return ErrMalformed
+	}
+	seq := binary.BigEndian.Uint64(payload[1:])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started && seq <= d.seq {
+		return ErrReplayed
+	}
+	d.seq, d.started = seq, true
+	d.on = payload[0]&flagDump != 0
+	return nil
+}
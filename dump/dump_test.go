@@ -0,0 +1,87 @@
+package dump
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+	"github.com/ianchildress/enproto/sign"
+)
+
+// TestDumper_Signed verifies signed DEBUG frames switch dumping on and off, and
+// unsigned ones are refused.
+func TestDumper_Signed(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fw := enproto.NewFrameWriter(&buf)
+	sw := sign.NewWriter(fw, priv)
+	fw.WriteFrame(0x01, []byte("before"))
+	sw.WriteFrame(TypeDebug, Toggle(true, 1))
+	fw.WriteFrame(0x01, []byte("during"))
+	sw.WriteFrame(TypeDebug, Toggle(false, 2))
+	fw.WriteFrame(0x01, []byte("after"))
+
+	var log strings.Builder
+	var passed int
+	d := New(enproto.HandlerFunc(func(byte, []byte) error {
+		passed++
+		return nil
+	}), &log)
+	h := sign.NewVerifier(d, sign.KeyVerifier(pub), sign.RequireSigned(TypeDebug))
+
+	fr := enproto.NewFrameReader(&buf)
+	for i := 0; i < 5; i++ {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.ServeFrame(msgType, payload); err != nil {
+			t.Fatalf("ServeFrame: %v", err)
+		}
+	}
+
+	if got, want := log.String(), "type=0x01 len=6 payload=\"during\"\n"; got != want {
+		t.Errorf("log = %q; want %q", got, want)
+	}
+	if passed != 3 {
+		t.Errorf("passed on %d frames; want 3", passed)
+	}
+
+	if err := h.ServeFrame(TypeDebug, Toggle(true, 3)); !errors.Is(err, sign.ErrUnsigned) {
+		t.Errorf("unsigned DEBUG frame = %v; want ErrUnsigned", err)
+	}
+	if d.Enabled() {
+		t.Error("unsigned DEBUG frame turned dumping on")
+	}
+}
+
+// TestDumper_Replay verifies DEBUG frames must carry increasing sequence numbers and
+// malformed ones are rejected.
+func TestDumper_Replay(t *testing.T) {
+	d := New(enproto.HandlerFunc(func(byte, []byte) error { return nil }), &bytes.Buffer{})
+	if err := d.ServeFrame(TypeDebug, Toggle(true, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ServeFrame(TypeDebug, Toggle(false, 6)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ServeFrame(TypeDebug, Toggle(true, 5)); !errors.Is(err, ErrReplayed) {
+		t.Errorf("replayed toggle = %v; want ErrReplayed", err)
+	}
+	if d.Enabled() {
+		t.Error("replayed toggle turned dumping on")
+	}
+
+	for _, p := range [][]byte{{flagDump}, {0x80, 0, 0, 0, 0, 0, 0, 0, 9}} {
+		if err := d.ServeFrame(TypeDebug, p); !errors.Is(err, ErrMalformed) {
+			t.Errorf("ServeFrame(%x) = %v; want ErrMalformed", p, err)
+		}
+	}
+}
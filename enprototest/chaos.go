@@ -0,0 +1,174 @@
+package enprototest
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrChaosDropped is returned by a Chaos transport once it has dropped the connection.
+var ErrChaosDropped = errors.New("enprototest: connection dropped by chaos transport")
+
+// ChaosConfig sets which faults a Chaos transport injects. Rates are probabilities
+// between 0 and 1; zero values disable the corresponding fault.
+type ChaosConfig struct {
+	// Seed makes the sequence of faults reproducible.
+	Seed uint64
+
+	// CorruptRate is the probability that each byte read or written has a bit flipped.
+	CorruptRate float64
+
+	// StallRate is the probability that a Read sleeps for StallDuration first.
+	StallRate     float64
+	StallDuration time.Duration
+
+	// TruncateRate is the probability that a Write sends only part of its data and
+	// then drops the connection, cutting a frame short on the wire.
+	TruncateRate float64
+
+	// DropRate is the probability that any Read or Write drops the connection.
+	DropRate float64
+
+	// DropAfter drops the connection once this many bytes have been transferred in
+	// either direction. Zero means never.
+	DropAfter int64
+}
+
+// Chaos wraps a transport and injects faults into the bytes passing through it, for
+// testing how applications cope with protocol-level failures. Dropping the connection
+// closes the wrapped transport if it is an io.Closer; every later Read or Write
+// returns ErrChaosDropped.
+type Chaos struct {
+	rw  io.ReadWriter
+	cfg ChaosConfig
+
+	mu      sync.Mutex // guards rng, moved and dropped
+	rng     *rand.Rand
+	moved   int64
+	dropped bool
+}
+
+// NewChaos wraps rw with the faults described by cfg.
+func NewChaos(rw io.ReadWriter, cfg ChaosConfig) *Chaos {
+	return &Chaos{
+		rw:  rw,
+		cfg: cfg,
+		rng: rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}
+}
+
+// roll reports whether an event with probability p happens.
+func (c *Chaos) roll(p float64) bool {
+	return p > 0 && c.rng.Float64() < p
+}
+
+// drop marks the connection dropped and closes the wrapped transport. c.mu must be held.
+func (c *Chaos) drop() error {
+	if !c.dropped {
+		c.dropped = true
+		if cl, ok := c.rw.(io.Closer); ok {
+			cl.Close()
+		}
+	}
+	return ErrChaosDropped
+}
+
+// corrupt flips bits in b at CorruptRate. c.mu must be held.
+func (c *Chaos) corrupt(b []byte) {
+	if c.cfg.CorruptRate <= 0 {
+		return
+	}
+	for i := range b {
+		if c.roll(c.cfg.CorruptRate) {
+			b[i] ^= 1 << c.rng.IntN(8)
+		}
+	}
+}
+
+// budget limits n to what may move before DropAfter, never below 0. Reads and writes
+// in flight are counted only once they finish, so concurrent calls can overshoot
+// DropAfter. c.mu must be held.
+func (c *Chaos) budget(n int) int {
+	if c.cfg.DropAfter <= 0 {
+		return n
+	}
+	return int(max(min(int64(n), c.cfg.DropAfter-c.moved), 0))
+}
+
+// Read reads from the wrapped transport, possibly stalling, corrupting the data or
+// dropping the connection.
+func (c *Chaos) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.dropped || c.roll(c.cfg.DropRate) {
+		defer c.mu.Unlock()
+		return 0, c.drop()
+	}
+	stall := c.roll(c.cfg.StallRate)
+	limit := c.budget(len(p))
+	if limit <= 0 && len(p) > 0 {
+		defer c.mu.Unlock()
+		return 0, c.drop()
+	}
+	c.mu.Unlock()
+
+	if stall {
+		time.Sleep(c.cfg.StallDuration)
+	}
+	n, err := c.rw.Read(p[:limit])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.corrupt(p[:n])
+	c.moved += int64(n)
+	return n, err
+}
+
+// Write writes to the wrapped transport, possibly corrupting the data, truncating it
+// or dropping the connection. The caller's buffer is never modified.
+func (c *Chaos) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.dropped || c.roll(c.cfg.DropRate) {
+		defer c.mu.Unlock()
+		return 0, c.drop()
+	}
+
+	limit := c.budget(len(p))
+	if limit <= 0 && len(p) > 0 {
+		defer c.mu.Unlock()
+		return 0, c.drop()
+	}
+	truncate := limit < len(p)
+	if !truncate && len(p) > 0 && c.roll(c.cfg.TruncateRate) {
+		limit, truncate = c.rng.IntN(len(p)), true
+	}
+
+	out := append([]byte(nil), p[:limit]...)
+	c.corrupt(out)
+	c.mu.Unlock()
+
+	n, err := c.rw.Write(out)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.moved += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if truncate {
+		return n, c.drop()
+	}
+	return n, nil
+}
+
+// Close closes the wrapped transport if it is an io.Closer.
+func (c *Chaos) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropped = true
+	if cl, ok := c.rw.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
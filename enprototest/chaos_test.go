@@ -0,0 +1,140 @@
+package enprototest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestChaos_Passthrough verifies a Chaos transport with no faults is transparent.
+func TestChaos_Passthrough(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := enproto.NewFramer(NewChaos(buf, ChaosConfig{}))
+
+	if err := fr.WriteFrame(0x1, []byte("clean")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	_, payload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if string(payload) != "clean" {
+		t.Errorf("payload = %q; want %q", payload, "clean")
+	}
+}
+
+// TestChaos_Corrupt verifies corruption changes bytes but not the caller's buffer.
+func TestChaos_Corrupt(t *testing.T) {
+	buf := &bytes.Buffer{}
+	c := NewChaos(buf, ChaosConfig{Seed: 1, CorruptRate: 1})
+
+	data := []byte("pristine")
+	if _, err := c.Write(data); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if string(data) != "pristine" {
+		t.Errorf("caller buffer modified: %q", data)
+	}
+	if bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("written bytes were not corrupted")
+	}
+}
+
+// TestChaos_Truncate ensures a truncated write cuts the frame off and drops the connection.
+func TestChaos_Truncate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := enproto.NewFrameWriter(NewChaos(buf, ChaosConfig{Seed: 1, TruncateRate: 1}))
+
+	payload := []byte("this frame will not arrive whole")
+	if err := fw.WriteFrame(0x1, payload); !errors.Is(err, ErrChaosDropped) {
+		t.Errorf("expected ErrChaosDropped, got %v", err)
+	}
+	if buf.Len() >= 8+len(payload) {
+		t.Errorf("wrote %d bytes; expected a truncated frame", buf.Len())
+	}
+
+	if _, _, err := enproto.NewFrameReader(buf).ReadFrame(); err == nil {
+		t.Errorf("expected reading the truncated frame to fail")
+	}
+}
+
+// TestChaos_DropAfter ensures the connection drops once the byte budget is spent.
+func TestChaos_DropAfter(t *testing.T) {
+	src := bytes.NewBuffer(MustEncode(enproto.Frame{Type: 0x1, Payload: make([]byte, 100)}))
+	c := NewChaos(src, ChaosConfig{DropAfter: 20})
+
+	n, err := io.Copy(io.Discard, c)
+	if !errors.Is(err, ErrChaosDropped) {
+		t.Errorf("expected ErrChaosDropped, got %v", err)
+	}
+	if n != 20 {
+		t.Errorf("transferred %d bytes; want 20", n)
+	}
+	if _, err := c.Write([]byte("x")); !errors.Is(err, ErrChaosDropped) {
+		t.Errorf("expected writes to fail after drop, got %v", err)
+	}
+}
+
+// slowPipe reads endless zero bytes and discards writes, holding each call open
+// briefly so reads and writes overlap and spend the budget under each other.
+type slowPipe struct{}
+
+func (slowPipe) Read(p []byte) (int, error) {
+	time.Sleep(100 * time.Microsecond)
+	clear(p)
+	return len(p), nil
+}
+
+func (slowPipe) Write(p []byte) (int, error) {
+	time.Sleep(100 * time.Microsecond)
+	return len(p), nil
+}
+
+// TestChaos_DropAfterConcurrent verifies concurrent reads and writes both end with a
+// drop once DropAfter is spent, even when one overshoots the budget of the other.
+func TestChaos_DropAfterConcurrent(t *testing.T) {
+	c := NewChaos(slowPipe{}, ChaosConfig{DropAfter: 1000})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p := make([]byte, 64)
+		for errs[0] == nil {
+			_, errs[0] = c.Read(p)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		p := make([]byte, 48)
+		for errs[1] == nil {
+			_, errs[1] = c.Write(p)
+		}
+	}()
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, ErrChaosDropped) {
+			t.Errorf("goroutine %d ended with %v; want ErrChaosDropped", i, err)
+		}
+	}
+}
+
+// TestChaos_Stall verifies reads are delayed.
+func TestChaos_Stall(t *testing.T) {
+	c := NewChaos(bytes.NewBuffer([]byte("slow")), ChaosConfig{StallRate: 1, StallDuration: 20 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := c.Read(make([]byte, 4)); err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("read returned after %v; expected a stall", elapsed)
+	}
+}
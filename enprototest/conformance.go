@@ -0,0 +1,178 @@
+package enprototest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// The conformance suite checks a peer implementation over a live connection. The
+// peer under test must behave as an echo peer:
+//
+//   - every valid frame it receives is written back unchanged, in order;
+//   - on a frame with a bad magic number, an unsupported version or a length above
+//     the default 100 MiB limit, it closes the connection without replying.
+//
+// Each case runs on a fresh connection.
+
+// ConformanceCase is one check in the conformance suite.
+type ConformanceCase struct {
+	Name string
+	Run  func(conn net.Conn, timeout time.Duration) error
+}
+
+// ConformanceResult is the outcome of one ConformanceCase; Err is nil if it passed.
+type ConformanceResult struct {
+	Name string
+	Err  error
+}
+
+// ConformanceCases returns the conformance suite.
+func ConformanceCases() []ConformanceCase {
+	return []ConformanceCase{
+		{"echo single frame", echoCase(enproto.Frame{Type: 0x01, Payload: []byte("conformance")})},
+		{"echo empty payload", echoCase(enproto.Frame{Type: 0x02})},
+		{"echo every message type", echoCase(everyType()...)},
+		{"echo pipelined frames", echoCase(pipelined()...)},
+		{"echo 1 MiB payload", echoCase(enproto.Frame{Type: 0x03, Payload: bytes.Repeat([]byte{0xA5}, 1<<20)})},
+		{"echo byte-at-a-time writes", trickleCase},
+		{"reject bad magic", rejectCase(header(^enproto.Magic, enproto.ProtocolVersion, 0x01, 0))},
+		{"reject bad version", rejectCase(header(enproto.Magic, enproto.ProtocolVersion+1, 0x01, 0))},
+		{"reject oversized length", rejectCase(header(enproto.Magic, enproto.ProtocolVersion, 0x01, defaultMaxFrameSize+1))},
+	}
+}
+
+// RunConformance runs every case on a connection from dial and returns the results.
+// timeout bounds each read and write.
+func RunConformance(dial func() (net.Conn, error), timeout time.Duration) []ConformanceResult {
+	cases := ConformanceCases()
+	results := make([]ConformanceResult, len(cases))
+	for i, c := range cases {
+		results[i] = ConformanceResult{Name: c.Name, Err: runCase(c, dial, timeout)}
+	}
+	return results
+}
+
+func runCase(c ConformanceCase, dial func() (net.Conn, error), timeout time.Duration) error {
+	conn, err := dial()
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+	return c.Run(conn, timeout)
+}
+
+func everyType() []enproto.Frame {
+	frames := make([]enproto.Frame, 256)
+	for i := range frames {
+		frames[i] = enproto.Frame{Type: byte(i), Payload: []byte{byte(i)}}
+	}
+	return frames
+}
+
+func pipelined() []enproto.Frame {
+	_, frames := NewGenerator(1).Stream(64)
+	return frames
+}
+
+// echoCase sends frames and expects them echoed back. Writing and reading run
+// concurrently so that peers echoing synchronously cannot deadlock the harness.
+func echoCase(frames ...enproto.Frame) func(net.Conn, time.Duration) error {
+	return func(conn net.Conn, timeout time.Duration) error {
+		var wire bytes.Buffer
+		for _, f := range frames {
+			wire.Write(MustEncode(f))
+		}
+		return sendAndExpect(conn, timeout, wire.Bytes(), 0, frames)
+	}
+}
+
+// trickleCase sends a frame one byte per write.
+func trickleCase(conn net.Conn, timeout time.Duration) error {
+	frame := enproto.Frame{Type: 0x04, Payload: []byte("trickled")}
+	return sendAndExpect(conn, timeout, MustEncode(frame), 1, []enproto.Frame{frame})
+}
+
+// sendAndExpect writes wire to conn, in chunks of chunk bytes if chunk > 0, while
+// reading back the expected frames.
+func sendAndExpect(conn net.Conn, timeout time.Duration, wire []byte, chunk int, want []enproto.Frame) error {
+	written := make(chan error, 1)
+	go func() {
+		conn.SetWriteDeadline(time.Now().Add(timeout))
+		if chunk <= 0 {
+			chunk = len(wire)
+		}
+		for len(wire) > 0 {
+			n := min(chunk, len(wire))
+			if _, err := conn.Write(wire[:n]); err != nil {
+				written <- fmt.Errorf("write: %w", err)
+				return
+			}
+			wire = wire[n:]
+		}
+		written <- nil
+	}()
+
+	fr := enproto.NewFrameReader(conn)
+	for i, f := range want {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			return fmt.Errorf("frame %d: read echo: %w", i, err)
+		}
+		if msgType != f.Type || !bytes.Equal(payload, f.Payload) {
+			return fmt.Errorf("frame %d: echoed type %#02x with %d bytes; want type %#02x with %d bytes",
+				i, msgType, len(payload), f.Type, len(f.Payload))
+		}
+	}
+	return <-written
+}
+
+// rejectCase sends an invalid header and expects the peer to close the connection.
+func rejectCase(wire []byte) func(net.Conn, time.Duration) error {
+	return func(conn net.Conn, timeout time.Duration) error {
+		conn.SetWriteDeadline(time.Now().Add(timeout))
+		if _, err := conn.Write(wire); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		var b [1]byte
+		n, err := conn.Read(b[:])
+		if n > 0 {
+			return errors.New("peer replied instead of closing the connection")
+		}
+		if err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+			return errors.New("peer did not close the connection")
+		}
+		// Closed, or reset by the peer.
+		return nil
+	}
+}
+
+// EchoPeer serves conn as a conforming echo peer until the connection ends or a
+// frame is invalid, then closes it. It is the reference implementation the suite is
+// checked against.
+func EchoPeer(conn net.Conn) error {
+	defer conn.Close()
+
+	fr := enproto.NewFramer(conn)
+	for {
+		msgType, payload, err := fr.ReadFrameSharedBuffer()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := fr.WriteFrame(msgType, payload); err != nil {
+			return err
+		}
+	}
+}
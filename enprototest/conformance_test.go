@@ -0,0 +1,60 @@
+package enprototest
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen serves every accepted connection with serve.
+func listen(t *testing.T, serve func(net.Conn)) func() (net.Conn, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return func() (net.Conn, error) { return net.Dial("tcp", ln.Addr().String()) }
+}
+
+// TestRunConformance_EchoPeer verifies the reference peer passes every case.
+func TestRunConformance_EchoPeer(t *testing.T) {
+	dial := listen(t, func(conn net.Conn) { EchoPeer(conn) })
+
+	for _, r := range RunConformance(dial, 5*time.Second) {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Name, r.Err)
+		}
+	}
+}
+
+// TestRunConformance_Lenient ensures a peer that never rejects input fails the reject cases.
+func TestRunConformance_Lenient(t *testing.T) {
+	// Echoes raw bytes without validating anything.
+	dial := listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		io.Copy(conn, conn)
+	})
+
+	results := RunConformance(dial, 200*time.Millisecond)
+	for _, r := range results {
+		rejecting := strings.HasPrefix(r.Name, "reject")
+		if rejecting && r.Err == nil {
+			t.Errorf("%s: lenient peer passed", r.Name)
+		}
+		if !rejecting && r.Err != nil {
+			t.Errorf("%s: %v", r.Name, r.Err)
+		}
+	}
+}
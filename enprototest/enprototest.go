@@ -0,0 +1,157 @@
+// Package enprototest generates enproto wire input for testing and fuzzing code
+// that consumes frames: valid frames, randomly mutated frames, and a fixed corpus
+// of adversarial byte sequences.
+//
+// All output uses the default magic number and protocol version.
+package enprototest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+
+	"github.com/ianchildress/enproto"
+)
+
+// defaultMaxFrameSize mirrors enproto's default payload limit (100 MiB).
+const defaultMaxFrameSize = 100 * 1024 * 1024
+
+// Encode returns the wire encoding of f. It fails with enproto.ErrFrameTooLarge for
+// payloads over the default 100 MiB limit.
+func Encode(f enproto.Frame) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := enproto.NewFrameWriter(&buf).WriteFrame(f.Type, f.Payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MustEncode is like Encode but panics if f cannot be encoded, for frames known to be
+// within the limit.
+func MustEncode(f enproto.Frame) []byte {
+	b, err := Encode(f)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// header returns a raw 8-byte header, with no validation.
+func header(magic uint16, version, msgType byte, length uint32) []byte {
+	return enproto.EncodeHeader(enproto.Header{Magic: magic, Version: version, Type: msgType, Length: length})
+}
+
+// Generator produces pseudo-random frames from a seed, so failures are reproducible.
+type Generator struct {
+	rng *rand.Rand
+
+	// MaxPayload bounds the payload size of generated frames. The default is 1 KiB.
+	MaxPayload int
+}
+
+// NewGenerator returns a Generator seeded with seed.
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{
+		rng:        rand.New(rand.NewPCG(seed, seed)),
+		MaxPayload: 1024,
+	}
+}
+
+// Frame returns a random frame.
+func (g *Generator) Frame() enproto.Frame {
+	payload := make([]byte, g.rng.IntN(g.MaxPayload+1))
+	for i := range payload {
+		payload[i] = byte(g.rng.Uint32())
+	}
+	return enproto.Frame{Type: byte(g.rng.Uint32()), Payload: payload}
+}
+
+// Valid returns the encoding of a random, well-formed frame.
+func (g *Generator) Valid() []byte {
+	return MustEncode(g.Frame())
+}
+
+// Stream returns n well-formed frames back to back, along with the frames encoded.
+func (g *Generator) Stream(n int) ([]byte, []enproto.Frame) {
+	var buf bytes.Buffer
+	frames := make([]enproto.Frame, n)
+	for i := range frames {
+		frames[i] = g.Frame()
+		buf.Write(MustEncode(frames[i]))
+	}
+	return buf.Bytes(), frames
+}
+
+// Mutated returns a valid frame with random damage applied: flipped bits, overwritten
+// header fields, truncation or trailing garbage.
+func (g *Generator) Mutated() []byte {
+	b := g.Valid()
+
+	for n := 1 + g.rng.IntN(3); n > 0; n-- {
+		switch g.rng.IntN(5) {
+		case 0: // flip a bit anywhere
+			i := g.rng.IntN(len(b))
+			b[i] ^= 1 << g.rng.IntN(8)
+		case 1: // corrupt a header byte
+			b[g.rng.IntN(8)] = byte(g.rng.Uint32())
+		case 2: // claim a different length
+			binary.BigEndian.PutUint32(b[4:8], g.rng.Uint32())
+		case 3: // truncate
+			b = b[:g.rng.IntN(len(b)+1)]
+		case 4: // append garbage
+			extra := make([]byte, 1+g.rng.IntN(16))
+			for i := range extra {
+				extra[i] = byte(g.rng.Uint32())
+			}
+			b = append(b, extra...)
+		}
+		if len(b) < 8 {
+			break
+		}
+	}
+	return b
+}
+
+// Adversarial returns a fixed corpus of hostile inputs: empty and truncated headers,
+// wrong magic and version, length claims at and beyond the limits with missing
+// payloads, and valid frames followed by partial ones.
+func Adversarial() [][]byte {
+	m, v := enproto.Magic, enproto.ProtocolVersion
+	valid := MustEncode(enproto.Frame{Type: 0x1, Payload: []byte("ok")})
+
+	corpus := [][]byte{
+		{},
+		header(m, v, 0x1, 0),                             // empty payload
+		header(^m, v, 0x1, 0),                            // bad magic
+		header(m, v+1, 0x1, 0),                           // bad version
+		header(m, v, 0x1, 16),                            // payload missing entirely
+		append(header(m, v, 0x1, 16), 'x'),               // payload cut short
+		header(m, v, 0x1, defaultMaxFrameSize),           // largest legal claim, no data
+		header(m, v, 0x1, defaultMaxFrameSize+1),         // just over the limit
+		header(m, v, 0x1, math.MaxUint32),                // maximum length claim
+		append(append([]byte{}, valid...), valid[:5]...), // valid frame then truncated header
+		append(append([]byte{}, valid...), header(^m, v, 0, 0)...), // valid frame then bad magic
+	}
+	// Every truncated header length.
+	full := header(m, v, 0x1, 0)
+	for n := 1; n < len(full); n++ {
+		corpus = append(corpus, full[:n])
+	}
+	// Every message type with an empty payload.
+	for t := 0; t < 256; t++ {
+		corpus = append(corpus, header(m, v, byte(t), 0))
+	}
+	return corpus
+}
+
+// Corpus returns the adversarial corpus followed by n valid and n mutated frames
+// from a Generator seeded with seed, ready to pass to testing.F.Add.
+func Corpus(seed uint64, n int) [][]byte {
+	g := NewGenerator(seed)
+	corpus := Adversarial()
+	for i := 0; i < n; i++ {
+		corpus = append(corpus, g.Valid(), g.Mutated())
+	}
+	return corpus
+}
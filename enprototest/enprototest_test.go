@@ -0,0 +1,59 @@
+package enprototest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestGenerator_Stream verifies generated frames decode to what was generated.
+func TestGenerator_Stream(t *testing.T) {
+	wire, frames := NewGenerator(1).Stream(20)
+
+	fr := enproto.NewFrameReader(bytes.NewReader(wire))
+	for i, want := range frames {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("frame %d: ReadFrame error: %v", i, err)
+		}
+		if msgType != want.Type || !bytes.Equal(payload, want.Payload) {
+			t.Errorf("frame %d: got type %d len %d; want type %d len %d", i, msgType, len(payload), want.Type, len(want.Payload))
+		}
+	}
+	if _, _, err := fr.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+// TestGenerator_Deterministic ensures the same seed yields the same output.
+func TestGenerator_Deterministic(t *testing.T) {
+	a, b := NewGenerator(7), NewGenerator(7)
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(a.Mutated(), b.Mutated()) {
+			t.Fatalf("output %d differs for the same seed", i)
+		}
+	}
+}
+
+// FuzzReadFrame checks that no input makes the reader panic or return a payload
+// inconsistent with its input.
+func FuzzReadFrame(f *testing.F) {
+	for _, b := range Corpus(1, 32) {
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		fr := enproto.NewFrameReader(bytes.NewReader(b))
+		for {
+			_, payload, err := fr.ReadFrame()
+			if err != nil {
+				return
+			}
+			if len(payload) > len(b) {
+				t.Fatalf("payload of %d bytes from %d bytes of input", len(payload), len(b))
+			}
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package enprototest
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Fixture is a named frame whose encoding is locked by golden tests.
+type Fixture struct {
+	// Name identifies the fixture. It must be a valid Go identifier, and is used as
+	// the variable name in generated source and the base name of binary files.
+	Name  string
+	Frame enproto.Frame
+}
+
+// encode returns the fixture's canonical encoding.
+func (fx Fixture) encode() ([]byte, error) {
+	b, err := Encode(fx.Frame)
+	if err != nil {
+		return nil, fmt.Errorf("enprototest: fixture %s: %w", fx.Name, err)
+	}
+	return b, nil
+}
+
+func checkNames(fixtures []Fixture) error {
+	seen := make(map[string]bool, len(fixtures))
+	for _, fx := range fixtures {
+		if !token.IsIdentifier(fx.Name) {
+			return fmt.Errorf("enprototest: fixture name %q is not a Go identifier", fx.Name)
+		}
+		if seen[fx.Name] {
+			return fmt.Errorf("enprototest: duplicate fixture name %q", fx.Name)
+		}
+		seen[fx.Name] = true
+	}
+	return nil
+}
+
+// WriteGoSource writes a gofmt-ed Go source file for package pkg declaring one
+// []byte variable per fixture, holding its canonical encoding.
+func WriteGoSource(w io.Writer, pkg string, fixtures []Fixture) error {
+	if err := checkNames(fixtures); err != nil {
+		return err
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by enprototest; DO NOT EDIT.\n\npackage %s\n", pkg)
+	for _, fx := range fixtures {
+		b, err := fx.encode()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&src, "\n// %s encodes a frame of type %#02x with a %d-byte payload.\n", fx.Name, fx.Frame.Type, len(fx.Frame.Payload))
+		fmt.Fprintf(&src, "var %s = []byte{", fx.Name)
+		for i, c := range b {
+			if i%12 == 0 {
+				src.WriteString("\n")
+			}
+			fmt.Fprintf(&src, "%#02x, ", c)
+		}
+		src.WriteString("\n}\n")
+	}
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// WriteFiles writes each fixture's canonical encoding to dir/<Name>.bin.
+func WriteFiles(dir string, fixtures []Fixture) error {
+	if err := checkNames(fixtures); err != nil {
+		return err
+	}
+	for _, fx := range fixtures {
+		b, err := fx.encode()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, fx.Name+".bin"), b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckFiles compares each fixture's current encoding with dir/<Name>.bin, as written
+// by WriteFiles, and reports the first mismatch.
+func CheckFiles(dir string, fixtures []Fixture) error {
+	for _, fx := range fixtures {
+		want, err := os.ReadFile(filepath.Join(dir, fx.Name+".bin"))
+		if err != nil {
+			return err
+		}
+		got, err := fx.encode()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			return fmt.Errorf("enprototest: fixture %s: encoding changed\n got: % x\nwant: % x", fx.Name, got, want)
+		}
+	}
+	return nil
+}
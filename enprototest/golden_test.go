@@ -0,0 +1,69 @@
+package enprototest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+var fixtures = []Fixture{
+	{Name: "goldenPing", Frame: enproto.Frame{Type: 0x01}},
+	{Name: "goldenHello", Frame: enproto.Frame{Type: 0x02, Payload: []byte("hello")}},
+}
+
+// TestWriteGoSource verifies the generated source declares every fixture's bytes.
+func TestWriteGoSource(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGoSource(&buf, "wire", fixtures); err != nil {
+		t.Fatalf("WriteGoSource error: %v", err)
+	}
+	src := buf.String()
+
+	for _, want := range []string{
+		"// Code generated by enprototest; DO NOT EDIT.",
+		"package wire",
+		"var goldenPing = []byte{",
+		"0x59, 0x59, 0x01, 0x02, 0x00, 0x00, 0x00, 0x05, 0x68, 0x65, 0x6c, 0x6c,",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated source missing %q:\n%s", want, src)
+		}
+	}
+
+	if err := WriteGoSource(&buf, "wire", []Fixture{{Name: "not valid"}}); err == nil {
+		t.Errorf("expected error for invalid fixture name")
+	}
+}
+
+// TestWriteCheckFiles verifies binary fixtures round-trip and detect drift.
+func TestWriteCheckFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteFiles(dir, fixtures); err != nil {
+		t.Fatalf("WriteFiles error: %v", err)
+	}
+	if err := CheckFiles(dir, fixtures); err != nil {
+		t.Errorf("CheckFiles error: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "goldenPing.bin"), []byte{0x00}, 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	if err := CheckFiles(dir, fixtures); err == nil || !strings.Contains(err.Error(), "goldenPing") {
+		t.Errorf("expected mismatch for goldenPing, got %v", err)
+	}
+}
+
+// TestWriteFiles_TooLarge verifies an unencodable fixture is reported, not panicked on.
+func TestWriteFiles_TooLarge(t *testing.T) {
+	// Never written to, so the pages of the oversized payload are not touched.
+	big := []Fixture{{Name: "goldenBig", Frame: enproto.Frame{Type: 0x01, Payload: make([]byte, defaultMaxFrameSize+1)}}}
+	err := WriteFiles(t.TempDir(), big)
+	if !errors.Is(err, enproto.ErrFrameTooLarge) || !strings.Contains(err.Error(), "goldenBig") {
+		t.Errorf("WriteFiles = %v; want ErrFrameTooLarge naming the fixture", err)
+	}
+}
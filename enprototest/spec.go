@@ -0,0 +1,191 @@
+package enprototest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Spec is a machine-readable description of the enproto frame layout.
+type Spec struct {
+	Protocol   string      `json:"protocol"`
+	Version    int         `json:"version"`
+	ByteOrder  string      `json:"byte_order"`
+	HeaderSize int         `json:"header_size"`
+	MaxPayload uint32      `json:"max_payload"`
+	Magic      uint16      `json:"magic"`
+	Fields     []SpecField `json:"fields"`
+	Errors     []SpecError `json:"errors"`
+}
+
+// SpecField describes one field of the frame layout.
+type SpecField struct {
+	Name        string `json:"name"`
+	Offset      int    `json:"offset"`
+	Size        int    `json:"size"` // -1 means given by the length field
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// SpecError names a failure a conforming reader must detect.
+type SpecError struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Error names used by Spec and test vectors.
+const (
+	ErrorBadMagic      = "bad_magic"
+	ErrorBadVersion    = "bad_version"
+	ErrorFrameTooLarge = "frame_too_large"
+	ErrorTruncated     = "truncated"
+)
+
+// WireSpec returns the description of the current frame layout.
+func WireSpec() Spec {
+	return Spec{
+		Protocol:   "enproto",
+		Version:    int(enproto.ProtocolVersion),
+		ByteOrder:  "big-endian",
+		HeaderSize: 8,
+		MaxPayload: defaultMaxFrameSize,
+		Magic:      enproto.Magic,
+		Fields: []SpecField{
+			{"magic", 0, 2, "uint16", "protocol magic number; frames with any other value are rejected"},
+			{"version", 2, 1, "uint8", "wire-format version; frames with an unsupported version are rejected"},
+			{"type", 3, 1, "uint8", "application message type"},
+			{"length", 4, 4, "uint32", "payload length in bytes; values above max_payload are rejected"},
+			{"payload", 8, -1, "bytes", "opaque payload of exactly length bytes"},
+		},
+		Errors: []SpecError{
+			{ErrorBadMagic, "magic field does not match"},
+			{ErrorBadVersion, "version field is not supported"},
+			{ErrorFrameTooLarge, "length field exceeds max_payload"},
+			{ErrorTruncated, "stream ended inside a header or payload"},
+		},
+	}
+}
+
+// Vector is an encoded input and what a conforming reader decodes from it: either a
+// sequence of frames, or the frames before Error and then Error.
+type Vector struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Wire        []byte        `json:"wire"` // base64 in JSON
+	Frames      []VectorFrame `json:"frames"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// VectorFrame is one decoded frame of a Vector.
+type VectorFrame struct {
+	Type    byte   `json:"type"`
+	Payload []byte `json:"payload"` // base64 in JSON
+}
+
+// TestVectors returns the encoded/decoded test vectors for the current layout.
+func TestVectors() []Vector {
+	m, v := enproto.Magic, enproto.ProtocolVersion
+	frame := func(t byte, p string) VectorFrame { return VectorFrame{Type: t, Payload: []byte(p)} }
+	encode := func(frames ...VectorFrame) []byte {
+		var b []byte
+		for _, f := range frames {
+			b = append(b, MustEncode(enproto.Frame{Type: f.Type, Payload: f.Payload})...)
+		}
+		return b
+	}
+
+	hello := frame(0x01, "hello")
+	empty := frame(0x00, "")
+	high := frame(0xFF, "\x00\x01\x02\xFF")
+
+	vectors := []Vector{
+		{Name: "single", Description: "one frame with a short payload", Wire: encode(hello), Frames: []VectorFrame{hello}},
+		{Name: "empty_payload", Description: "a zero-length payload", Wire: encode(empty), Frames: []VectorFrame{empty}},
+		{Name: "binary_payload", Description: "highest message type and non-text payload", Wire: encode(high), Frames: []VectorFrame{high}},
+		{Name: "sequence", Description: "frames back to back", Wire: encode(hello, empty, high), Frames: []VectorFrame{hello, empty, high}},
+		{Name: "bad_magic", Description: "wrong magic number", Wire: header(^m, v, 0x01, 0), Error: ErrorBadMagic},
+		{Name: "bad_version", Description: "unsupported version", Wire: header(m, v+1, 0x01, 0), Error: ErrorBadVersion},
+		{Name: "too_large", Description: "length one byte over the limit", Wire: header(m, v, 0x01, defaultMaxFrameSize+1), Error: ErrorFrameTooLarge},
+		{Name: "truncated_header", Description: "stream ends inside a header", Wire: header(m, v, 0x01, 0)[:5], Error: ErrorTruncated},
+		{Name: "truncated_payload", Description: "stream ends inside a payload", Wire: encode(hello)[:10], Error: ErrorTruncated},
+		{Name: "error_after_frame", Description: "a valid frame followed by a bad one", Wire: append(encode(hello), header(^m, v, 0, 0)...), Frames: []VectorFrame{hello}, Error: ErrorBadMagic},
+	}
+	// Encode "no frames" as an empty JSON array rather than null.
+	for i := range vectors {
+		if vectors[i].Frames == nil {
+			vectors[i].Frames = []VectorFrame{}
+		}
+	}
+	return vectors
+}
+
+// Check decodes vec.Wire with an enproto FrameReader and reports any difference from
+// the expected frames and error.
+func (vec Vector) Check() error {
+	fr := enproto.NewFrameReader(bytes.NewReader(vec.Wire))
+	for i, want := range vec.Frames {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			return fmt.Errorf("%s: frame %d: %w", vec.Name, i, err)
+		}
+		if msgType != want.Type || !bytes.Equal(payload, want.Payload) {
+			return fmt.Errorf("%s: frame %d: got type %#02x payload %x; want type %#02x payload %x",
+				vec.Name, i, msgType, payload, want.Type, want.Payload)
+		}
+	}
+
+	_, _, err := fr.ReadFrame()
+	if got := errorName(err); got != vec.Error {
+		return fmt.Errorf("%s: error = %q (%v); want %q", vec.Name, got, err, vec.Error)
+	}
+	return nil
+}
+
+// errorName maps a ReadFrame error to its spec name; clean EOF maps to "".
+func errorName(err error) string {
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+		return ""
+	case errors.Is(err, enproto.ErrBadMagic):
+		return ErrorBadMagic
+	case errors.Is(err, enproto.ErrBadVersion):
+		return ErrorBadVersion
+	case errors.Is(err, enproto.ErrFrameTooLarge):
+		return ErrorFrameTooLarge
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorTruncated
+	}
+	return err.Error()
+}
+
+// ExportSpec writes spec.json, vectors.json and one <name>.bin file per vector into
+// dir, for implementations in other languages to verify against.
+func ExportSpec(dir string) error {
+	if err := writeJSON(filepath.Join(dir, "spec.json"), WireSpec()); err != nil {
+		return err
+	}
+	vectors := TestVectors()
+	if err := writeJSON(filepath.Join(dir, "vectors.json"), vectors); err != nil {
+		return err
+	}
+	for _, vec := range vectors {
+		if err := os.WriteFile(filepath.Join(dir, vec.Name+".bin"), vec.Wire, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,61 @@
+package enprototest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTestVectors verifies every vector decodes as described.
+func TestTestVectors(t *testing.T) {
+	for _, vec := range TestVectors() {
+		if err := vec.Check(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestExportSpec verifies the exported files describe the same vectors.
+func TestExportSpec(t *testing.T) {
+	dir := t.TempDir()
+	if err := ExportSpec(dir); err != nil {
+		t.Fatalf("ExportSpec error: %v", err)
+	}
+
+	var spec Spec
+	readJSON(t, filepath.Join(dir, "spec.json"), &spec)
+	if spec.HeaderSize != 8 || len(spec.Fields) != 5 {
+		t.Errorf("spec = %+v; want an 8-byte header with 5 fields", spec)
+	}
+
+	var vectors []Vector
+	readJSON(t, filepath.Join(dir, "vectors.json"), &vectors)
+	if len(vectors) != len(TestVectors()) {
+		t.Fatalf("exported %d vectors; want %d", len(vectors), len(TestVectors()))
+	}
+	for _, vec := range vectors {
+		if err := vec.Check(); err != nil {
+			t.Errorf("exported vector: %v", err)
+		}
+		bin, err := os.ReadFile(filepath.Join(dir, vec.Name+".bin"))
+		if err != nil {
+			t.Fatalf("ReadFile error: %v", err)
+		}
+		if !bytes.Equal(bin, vec.Wire) {
+			t.Errorf("%s.bin differs from vectors.json", vec.Name)
+		}
+	}
+}
+
+func readJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("Unmarshal %s error: %v", path, err)
+	}
+}
@@ -0,0 +1,60 @@
+package enproto
+
+import "expvar"
+
+// WithExpvar counts the frames and bytes a Framer reads and writes into m, under the
+// keys frames_read, bytes_read, frames_written and bytes_written. Bytes include frame
+// headers, and frames skipped by a Filter or SkipFrame count as read.
+//
+// Several Framers may share one map to report totals, or each may get its own, set
+// in a parent map published with expvar.Publish:
+//
+//	conns := expvar.NewMap("enproto")
+//	m := new(expvar.Map)
+//	conns.Set(conn.RemoteAddr().String(), m)
+//	f := enproto.NewFramer(conn, enproto.WithExpvar(m))
+//
+// Existing entries under those keys must be *expvar.Int; counting resumes from them.
+func WithExpvar(m *expvar.Map) Option {
+	return func(c *config) {
+		c.counters = newFrameCounters(m)
+	}
+}
+
+// frameCounters holds the expvar counters a Framer updates. A nil *frameCounters
+// counts nothing.
+type frameCounters struct {
+	framesRead, bytesRead       *expvar.Int
+	framesWritten, bytesWritten *expvar.Int
+}
+
+func newFrameCounters(m *expvar.Map) *frameCounters {
+	counter := func(key string) *expvar.Int {
+		m.Add(key, 0) // creates the entry if it is missing
+		return m.Get(key).(*expvar.Int)
+	}
+	return &frameCounters{
+		framesRead:    counter("frames_read"),
+		bytesRead:     counter("bytes_read"),
+		framesWritten: counter("frames_written"),
+		bytesWritten:  counter("bytes_written"),
+	}
+}
+
+// read counts a frame whose header announced a length-byte payload.
+func (c *frameCounters) read(length uint32) {
+	if c == nil {
+		return
+	}
+	c.framesRead.Add(1)
+	c.bytesRead.Add(HeaderSize + int64(length))
+}
+
+// wrote counts a frame with a length-byte payload.
+func (c *frameCounters) wrote(length uint64) {
+	if c == nil {
+		return
+	}
+	c.framesWritten.Add(1)
+	c.bytesWritten.Add(HeaderSize + int64(length))
+}
@@ -0,0 +1,58 @@
+package enproto
+
+import (
+	"bytes"
+	"expvar"
+	"testing"
+)
+
+// TestWithExpvar verifies frames and bytes are counted in both directions, including
+// frames a Filter skips.
+func TestWithExpvar(t *testing.T) {
+	m := new(expvar.Map)
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf, WithExpvar(m))
+	if err := fw.WriteFrame(0x01, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.WriteFrameV(0x02, []byte("ab"), []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+
+	skip := func(h Header) Action {
+		if h.Type == 0x01 {
+			return ActionSkip
+		}
+		return ActionDeliver
+	}
+	fr := NewFrameReader(&buf, WithExpvar(m), WithFilter(skip))
+	if _, _, err := fr.ReadFrame(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{
+		"frames_written": 2,
+		"bytes_written":  2*HeaderSize + 8,
+		"frames_read":    2,
+		"bytes_read":     2*HeaderSize + 8,
+	}
+	for key, n := range want {
+		if got := m.Get(key).(*expvar.Int).Value(); got != n {
+			t.Errorf("%s = %d; want %d", key, got, n)
+		}
+	}
+}
+
+// TestWithExpvar_Shared verifies a second Framer resumes the counters in a shared map.
+func TestWithExpvar_Shared(t *testing.T) {
+	m := new(expvar.Map)
+	for i := 0; i < 2; i++ {
+		fw := NewFrameWriter(&bytes.Buffer{}, WithExpvar(m))
+		if err := fw.WriteFrame(0x01, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := m.Get("frames_written").String(); got != "2" {
+		t.Errorf("frames_written = %s; want 2", got)
+	}
+}
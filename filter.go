@@ -0,0 +1,59 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Action is a Filter's verdict on an incoming frame.
+type Action int
+
+const (
+	// ActionDeliver hands the frame to the caller as usual.
+	ActionDeliver Action = iota
+	// ActionSkip discards the frame's payload unread and moves on to the next frame.
+	ActionSkip
+	// ActionError discards the frame's payload and fails the read with ErrFrameRejected.
+	ActionError
+)
+
+// ErrFrameRejected is wrapped by read errors for frames a Filter answered with ActionError.
+var ErrFrameRejected = errors.New("frame rejected by filter")
+
+// Filter decides what to do with a frame once its header has been parsed and
+// validated, before any payload bytes are read.
+type Filter func(Header) Action
+
+// WithFilter installs a Filter consulted by ReadFrame and ReadFrameSharedBuffer for
+// every incoming frame, so policies such as type allowlists or size limits are
+// enforced before payloads are read. PeekFrameHeader and SkipFrame ignore it.
+func WithFilter(f Filter) Option {
+	return func(c *config) {
+		c.filter = f
+	}
+}
+
+// filteredHeader consumes headers until one passes the configured Filter, discarding
+// the payloads of skipped and rejected frames.
+func (r *FrameReader) filteredHeader() (Header, error) {
+	for {
+		h, err := r.nextHeader()
+		if err != nil {
+			return Header{}, err
+		}
+		if r.cfg.filter == nil {
+			return h, nil
+		}
+
+		action := r.cfg.filter(h)
+		if action == ActionDeliver {
+			return h, nil
+		}
+		if err := r.discardPayload(h); err != nil {
+			return Header{}, err
+		}
+		if action == ActionError {
+			return Header{}, fmt.Errorf("%w: type %d", ErrFrameRejected, h.Type)
+		}
+	}
+}
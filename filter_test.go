@@ -0,0 +1,66 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestWithFilter_Skip verifies skipped frames are never delivered.
+func TestWithFilter_Skip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	allowEven := func(h Header) Action {
+		if h.Type%2 == 0 {
+			return ActionDeliver
+		}
+		return ActionSkip
+	}
+	fr := NewFramer(buf, WithFilter(allowEven))
+
+	for msgType := byte(1); msgType <= 4; msgType++ {
+		if err := fr.WriteFrame(msgType, []byte{msgType}); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	for _, want := range []byte{2, 4} {
+		gotType, gotPayload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame error: %v", err)
+		}
+		if gotType != want || !bytes.Equal(gotPayload, []byte{want}) {
+			t.Errorf("frame = %d %v; want %d %v", gotType, gotPayload, want, []byte{want})
+		}
+	}
+}
+
+// TestWithFilter_Error ensures rejected frames fail the read and leave the stream aligned.
+func TestWithFilter_Error(t *testing.T) {
+	buf := &bytes.Buffer{}
+	rejectLarge := func(h Header) Action {
+		if h.Length > 4 {
+			return ActionError
+		}
+		return ActionDeliver
+	}
+	fr := NewFramer(buf, WithFilter(rejectLarge))
+
+	if err := fr.WriteFrame(0x1, []byte("too large")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if err := fr.WriteFrame(0x2, []byte("ok")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	if _, _, err := fr.ReadFrame(); !errors.Is(err, ErrFrameRejected) {
+		t.Errorf("expected ErrFrameRejected, got %v", err)
+	}
+
+	gotType, gotPayload, err := fr.ReadFrameSharedBuffer()
+	if err != nil {
+		t.Fatalf("ReadFrameSharedBuffer error: %v", err)
+	}
+	if gotType != 0x2 || string(gotPayload) != "ok" {
+		t.Errorf("frame = %d %q; want %d %q", gotType, gotPayload, 0x2, "ok")
+	}
+}
@@ -0,0 +1,85 @@
+package enproto
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultPreview is the number of payload bytes a zero Formatter shows.
+const defaultPreview = 32
+
+// Formatter renders frames as single-line annotated text for logs and debugging:
+//
+//	type=0x01 (Hello) len=5 payload="hello"
+//	type=0x02 len=40 payload=00 01 02 03 … (+8 bytes)
+//
+// Payloads that are printable UTF-8 are quoted; anything else is shown as hex.
+type Formatter struct {
+	// Names maps message types to names shown next to the type. It may be nil.
+	Names map[byte]string
+
+	// Preview is the maximum number of payload bytes shown. Zero means 32;
+	// a negative value hides the payload.
+	Preview int
+}
+
+// Format renders f.
+func (fm Formatter) Format(f Frame) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "type=%#02x", f.Type)
+	if name, ok := fm.Names[f.Type]; ok {
+		fmt.Fprintf(&b, " (%s)", name)
+	}
+	fmt.Fprintf(&b, " len=%d", len(f.Payload))
+
+	n := fm.Preview
+	if n == 0 {
+		n = defaultPreview
+	}
+	if n < 0 || len(f.Payload) == 0 {
+		return b.String()
+	}
+
+	shown := f.Payload[:min(n, len(f.Payload))]
+	if printable(shown) {
+		// Don't show half of a character cut off by the preview limit.
+		for len(shown) > 0 && !utf8.Valid(shown) {
+			shown = shown[:len(shown)-1]
+		}
+		fmt.Fprintf(&b, " payload=%q", shown)
+	} else {
+		fmt.Fprintf(&b, " payload=% x", shown)
+	}
+	if rest := len(f.Payload) - len(shown); rest > 0 {
+		fmt.Fprintf(&b, " … (+%d bytes)", rest)
+	}
+	return b.String()
+}
+
+// printable reports whether p is valid UTF-8 made of printable characters and
+// whitespace. A multi-byte character cut off at the end of p is allowed.
+func printable(p []byte) bool {
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && size <= 1 {
+			return !utf8.FullRune(p) && len(p) < utf8.UTFMax
+		}
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+		p = p[size:]
+	}
+	return true
+}
+
+// String renders f with the default Formatter.
+func (f Frame) String() string {
+	return Formatter{}.Format(f)
+}
+
+// String renders h as annotated text.
+func (h Header) String() string {
+	return fmt.Sprintf("magic=%#04x version=%d type=%#02x len=%d", h.Magic, h.Version, h.Type, h.Length)
+}
@@ -0,0 +1,40 @@
+package enproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestFormatter_Format covers names, text and binary previews, and truncation.
+func TestFormatter_Format(t *testing.T) {
+	fm := Formatter{Names: map[byte]string{0x01: "Hello"}, Preview: 4}
+
+	tests := []struct {
+		frame Frame
+		want  string
+	}{
+		{Frame{Type: 0x01, Payload: []byte("hi")}, `type=0x01 (Hello) len=2 payload="hi"`},
+		{Frame{Type: 0x02}, `type=0x02 len=0`},
+		{Frame{Type: 0x03, Payload: []byte{0, 1, 2}}, `type=0x03 len=3 payload=00 01 02`},
+		{Frame{Type: 0x01, Payload: []byte("hello world")}, `type=0x01 (Hello) len=11 payload="hell" … (+7 bytes)`},
+		{Frame{Type: 0x04, Payload: []byte("é€")}, `type=0x04 len=5 payload="é" … (+3 bytes)`},
+	}
+	for _, tt := range tests {
+		if got := fm.Format(tt.frame); got != tt.want {
+			t.Errorf("Format(%d, %q) = %s; want %s", tt.frame.Type, tt.frame.Payload, got, tt.want)
+		}
+	}
+
+	if got := (Formatter{Preview: -1}).Format(Frame{Type: 0x01, Payload: []byte("secret")}); got != "type=0x01 len=6" {
+		t.Errorf("hidden payload rendered as %s", got)
+	}
+}
+
+// TestFrame_String verifies the default preview length.
+func TestFrame_String(t *testing.T) {
+	got := Frame{Type: 0xFF, Payload: bytes.Repeat([]byte("a"), 40)}.String()
+	want := `type=0xff len=40 payload="aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" … (+8 bytes)`
+	if got != want {
+		t.Errorf("String() = %s; want %s", got, want)
+	}
+}
@@ -1,10 +1,8 @@
 package enproto
 
 import (
-	"bufio"
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"io"
 )
 
@@ -14,6 +12,9 @@ const (
 
 	// 100 MiB
 	maxAllowed uint32 = 100 * 1024 * 1024
+
+	// HeaderSize is the length in bytes of the fixed frame header.
+	HeaderSize = 8
 )
 
 var header [8]byte
@@ -21,144 +22,79 @@ var header [8]byte
 var (
 	ErrBadMagic   = errors.New("invalid magic number")
 	ErrBadVersion = errors.New("unsupported protocol version")
-)
 
-// Framer handles our length‐prefixed, versioned frames.
-type Framer struct {
-	br *bufio.Reader
-	bw *bufio.Writer
+	// ErrFrameTooLarge is wrapped by errors for frames whose payload exceeds the size limit.
+	ErrFrameTooLarge = errors.New("frame too large")
+)
 
-	rbuf []byte // reusable read payload buffer
+// Frame is a single decoded message: its type and payload.
+type Frame struct {
+	Type    byte
+	Payload []byte
 }
 
-func NewFramer(rw io.ReadWriter) *Framer {
-	return &Framer{
-		br: bufio.NewReaderSize(rw, 64*1024),
-		bw: bufio.NewWriterSize(rw, 64*1024), // 64KB buffer
-	}
+// Header is the fixed header that precedes every payload on the wire:
+// [2B Magic][1B Version][1B Type][4B Length], big-endian.
+type Header struct {
+	Magic   uint16
+	Version byte
+	Type    byte
+	Length  uint32
 }
 
-// WriteFrame writes a frame and flushes immediately (compat behavior).
-func (f *Framer) WriteFrame(msgType byte, payload []byte) error {
-	if err := f.WriteFrameBuffered(msgType, payload); err != nil {
-		return err
+// decodeHeader parses a header from the first HeaderSize bytes of b.
+func decodeHeader(b []byte) Header {
+	return Header{
+		Magic:   binary.BigEndian.Uint16(b[0:2]),
+		Version: b[2],
+		Type:    b[3],
+		Length:  binary.BigEndian.Uint32(b[4:8]),
 	}
-	return f.bw.Flush()
 }
 
-// WriteFrameBuffered writes a frame to the internal buffer.
-// Call Flush to ensure data is sent to the underlying writer.
-func (f *Framer) WriteFrameBuffered(msgType byte, payload []byte) error {
-	var header [8]byte
-	binary.BigEndian.PutUint16(header[0:2], Magic)
-	header[2] = ProtocolVersion
-	header[3] = msgType
-	binary.BigEndian.PutUint32(header[4:8], uint32(len(payload)))
-
-	if _, err := f.bw.Write(header[:]); err != nil {
-		return err
-	}
-	if _, err := f.bw.Write(payload); err != nil {
-		return err
-	}
-
-	return nil
+// DecodeHeader parses the frame header at the start of b, without needing a Framer.
+// It returns io.ErrUnexpectedEOF if b is shorter than HeaderSize. Otherwise it checks
+// the header against the default magic, version and 100 MiB size limit and returns
+// the decoded header even if that check fails, so tools can still show it.
+func DecodeHeader(b []byte) (Header, error) {
+	if len(b) < HeaderSize {
+		return Header{}, io.ErrUnexpectedEOF
+	}
+	h := decodeHeader(b)
+	cfg := newConfig(nil)
+	return h, cfg.validateHeader(h)
 }
 
-// Flush flushes the buffered writer.
-func (f *Framer) Flush() error {
-	return f.bw.Flush()
+// EncodeHeader returns the wire encoding of h.
+func EncodeHeader(h Header) []byte {
+	return AppendHeader(make([]byte, 0, HeaderSize), h)
 }
 
-// ReadFrame reads the next frame from the underlying reader and validates the protocol header.
-// It returns the message type and a payload slice.
-//
-// Note: This function allocates a new byte slice for the payload on every call,
-// making it safe for the caller to retain or mutate the returned data indefinitely.
-func (f *Framer) ReadFrame() (msgType byte, payload []byte, err error) {
-	// Protocol header is 8 bytes: [2B Magic][1B Version][1B Type][4B Length]
-	var header [8]byte
-	if _, err = io.ReadFull(f.br, header[:]); err != nil {
-		return 0, nil, err
-	}
-
-	// Validate protocol constraints to avoid processing malformed data.
-	if magic := binary.BigEndian.Uint16(header[0:2]); magic != Magic {
-		return 0, nil, ErrBadMagic
-	}
-	if version := header[2]; version != ProtocolVersion {
-		return 0, nil, ErrBadVersion
-	}
-	msgType = header[3]
-
-	length := binary.BigEndian.Uint32(header[4:8])
-	if length > maxAllowed {
-		return 0, nil, fmt.Errorf("frame too large: %d", length)
-	}
-
-	// Explicitly allocate a new slice to hold the incoming data.
-	// This ensures that the returned payload is independent of any internal framer buffers.
-	payload = make([]byte, length)
-	if _, err = io.ReadFull(f.br, payload); err != nil {
-		return 0, nil, err
-	}
-
-	return msgType, payload, nil
+// AppendHeader appends the wire encoding of h to dst and returns the extended slice.
+func AppendHeader(dst []byte, h Header) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, h.Magic)
+	dst = append(dst, h.Version, h.Type)
+	return binary.BigEndian.AppendUint32(dst, h.Length)
 }
 
-// ReadFrameSharedBuffer reads the next frame, validates header, and returns msgType + payload.
-// NOTE: payload is backed by an internal reusable buffer and is only valid until
-// the next ReadFrameSharedBuffer call on this Framer.
-func (f *Framer) ReadFrameSharedBuffer() (msgType byte, payload []byte, err error) {
-	var header [8]byte
-	if _, err = io.ReadFull(f.br, header[:]); err != nil {
-		return 0, nil, err
-	}
-
-	if magic := binary.BigEndian.Uint16(header[0:2]); magic != Magic {
-		return 0, nil, ErrBadMagic
-	}
-	if version := header[2]; version != ProtocolVersion {
-		return 0, nil, ErrBadVersion
-	}
-	msgType = header[3]
-
-	length := int(binary.BigEndian.Uint32(header[4:8]))
-	if uint32(length) > maxAllowed {
-		return 0, nil, fmt.Errorf("frame too large: %d", length)
-	}
-
-	// Ensure reusable buffer is large enough.
-	if cap(f.rbuf) < length {
-		// Grow to at least length; optionally over-allocate to reduce future grows.
-		// This is a normal heap allocation but happens rarely (only when size increases).
-		newCap := cap(f.rbuf) * 2
-		if newCap < length {
-			newCap = length
-		}
-		f.rbuf = make([]byte, newCap)
-	}
-
-	payload = f.rbuf[:length]
-	if _, err = io.ReadFull(f.br, payload); err != nil {
-		return 0, nil, err
-	}
-	return msgType, payload, nil
+// Framer handles our length‐prefixed, versioned frames in both directions.
+// It composes a FrameReader and a FrameWriter over the same transport.
+type Framer struct {
+	*FrameReader
+	*FrameWriter
 }
 
-// WriteBuffered returns the number of bytes currently queued in the write buffer.
-func (f *Framer) WriteBuffered() int {
-	if f.bw == nil {
-		return 0
-	}
-	return f.bw.Buffered()
+// NewFramer wraps rw with our framing logic, configured by opts.
+func NewFramer(rw io.ReadWriter, opts ...Option) *Framer {
+	return NewFramerRW(rw, rw, opts...)
 }
 
-// ReadBuffered returns the number of bytes currently buffered and ready to be read
-// without reading from the underlying io.Reader.
-func (f *Framer) ReadBuffered() int {
-	if f.br == nil {
-		return 0
+// NewFramerRW is like NewFramer for transports that expose separate read and write
+// endpoints, such as a subprocess's stdout and stdin.
+func NewFramerRW(r io.Reader, w io.Writer, opts ...Option) *Framer {
+	cfg := newConfig(opts)
+	return &Framer{
+		FrameReader: newFrameReader(r, cfg),
+		FrameWriter: newFrameWriter(w, cfg),
 	}
-	return f.br.Buffered()
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"io"
 	"strings"
 	"testing"
 )
@@ -36,6 +37,34 @@ func TestFramer_WriteReadFrame(t *testing.T) {
 	}
 }
 
+// TestNewFramerRW verifies a Framer over separate reader and writer endpoints.
+func TestNewFramerRW(t *testing.T) {
+	in := &bytes.Buffer{}
+	out := &bytes.Buffer{}
+	fr := NewFramerRW(in, out)
+
+	if err := fr.WriteFrame(0x1, []byte("outbound")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if in.Len() != 0 {
+		t.Errorf("expected write to leave reader endpoint untouched, got %d bytes", in.Len())
+	}
+
+	// Echo what was written back into the read endpoint.
+	in.Write(out.Bytes())
+
+	gotType, gotPayload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if gotType != 0x1 {
+		t.Errorf("message type = %d; want %d", gotType, 0x1)
+	}
+	if !bytes.Equal(gotPayload, []byte("outbound")) {
+		t.Errorf("payload = %q; want %q", gotPayload, "outbound")
+	}
+}
+
 // TestFramer_ReadFrame_TooLarge ensures ReadFrame rejects payloads exceeding maxAllowed.
 func TestFramer_ReadFrame_TooLarge(t *testing.T) {
 	buf := &bytes.Buffer{}
@@ -237,3 +266,45 @@ func TestFramer_ReadBuffered(t *testing.T) {
 		t.Errorf("expected 0 buffered bytes after all reads, got %d", buffered)
 	}
 }
+
+// TestEncodeDecodeHeader verifies the exported header codec round-trips and matches the writer.
+func TestEncodeDecodeHeader(t *testing.T) {
+	want := Header{Magic: Magic, Version: ProtocolVersion, Type: 0x42, Length: 3}
+
+	buf := &bytes.Buffer{}
+	if err := NewFrameWriter(buf).WriteFrame(0x42, []byte("abc")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if enc := EncodeHeader(want); !bytes.Equal(enc, buf.Bytes()[:HeaderSize]) {
+		t.Errorf("EncodeHeader = %x; want %x", enc, buf.Bytes()[:HeaderSize])
+	}
+
+	got, err := DecodeHeader(buf.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeHeader error: %v", err)
+	}
+	if got != want {
+		t.Errorf("DecodeHeader = %+v; want %+v", got, want)
+	}
+}
+
+// TestDecodeHeader_Errors covers short input and headers that fail validation.
+func TestDecodeHeader_Errors(t *testing.T) {
+	if _, err := DecodeHeader([]byte{0x59, 0x59}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	bad := Header{Magic: 0xFFFF, Version: ProtocolVersion, Type: 0x1}
+	got, err := DecodeHeader(EncodeHeader(bad))
+	if !errors.Is(err, ErrBadMagic) {
+		t.Errorf("expected ErrBadMagic, got %v", err)
+	}
+	if got != bad {
+		t.Errorf("DecodeHeader = %+v; want the decoded header %+v", got, bad)
+	}
+
+	large := Header{Magic: Magic, Version: ProtocolVersion, Length: maxAllowed + 1}
+	if _, err := DecodeHeader(EncodeHeader(large)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
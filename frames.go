@@ -0,0 +1,48 @@
+package enproto
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Frames starts a goroutine that reads frames with ReadFrame and delivers them on
+// the returned channel, buffered to hold up to buffer frames. This lets select-based
+// code handle incoming frames alongside timers and other event sources.
+//
+// When reading stops, the terminating error is sent on the error channel and both
+// channels are closed. A clean end of stream (io.EOF between frames) closes the
+// channels without sending an error, so once the frame channel is drained a receive
+// from the error channel yields nil on success. Cancelling ctx stops delivery and
+// reports ctx.Err(); a ReadFrame already blocked on the underlying reader still has
+// to return first, so close the connection to stop the goroutine promptly.
+//
+// The FrameReader must not be read from by anyone else while the goroutine is running.
+func (r *FrameReader) Frames(ctx context.Context, buffer int) (<-chan Frame, <-chan error) {
+	frames := make(chan Frame, buffer)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(frames)
+
+		for {
+			msgType, payload, err := r.ReadFrame()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					errs <- err
+				}
+				return
+			}
+
+			select {
+			case frames <- Frame{Type: msgType, Payload: payload}:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return frames, errs
+}
@@ -0,0 +1,81 @@
+package enproto
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestFramer_Frames verifies frames are delivered in order and a clean EOF closes both channels.
+func TestFramer_Frames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf)
+
+	payloads := [][]byte{[]byte("one"), []byte("two"), []byte("three")}
+	for i, p := range payloads {
+		if err := fr.WriteFrame(byte(i), p); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	frames, errs := fr.Frames(context.Background(), 1)
+
+	var i int
+	for got := range frames {
+		if got.Type != byte(i) {
+			t.Errorf("frame %d: message type = %d; want %d", i, got.Type, i)
+		}
+		if !bytes.Equal(got.Payload, payloads[i]) {
+			t.Errorf("frame %d: payload = %q; want %q", i, got.Payload, payloads[i])
+		}
+		i++
+	}
+	if i != len(payloads) {
+		t.Errorf("received %d frames; want %d", i, len(payloads))
+	}
+
+	if err := <-errs; err != nil {
+		t.Errorf("expected nil error after clean EOF, got %v", err)
+	}
+}
+
+// TestFramer_Frames_Error ensures a read error is reported on the error channel.
+func TestFramer_Frames_Error(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf)
+
+	// A truncated header is not a clean end of stream.
+	buf.Write([]byte{0x59, 0x59, ProtocolVersion})
+
+	frames, errs := fr.Frames(context.Background(), 0)
+	for range frames {
+		t.Errorf("unexpected frame")
+	}
+
+	if err := <-errs; !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+// TestFramer_Frames_Cancel ensures cancelling the context stops delivery.
+func TestFramer_Frames_Cancel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf)
+
+	for i := 0; i < 3; i++ {
+		if err := fr.WriteFrame(0x1, []byte("payload")); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Unbuffered, and nobody receives frames, so the goroutine must observe ctx.
+	_, errs := fr.Frames(ctx, 0)
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
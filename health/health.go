@@ -0,0 +1,152 @@
+// Package health measures a connection's round-trip time with HEALTH frames, for
+// load balancers and adaptive timeouts.
+//
+// A HEALTH frame is [1B kind][8B timestamp], big-endian. A probe (kind 0) carries the
+// sender's timestamp; the receiver answers with an echo (kind 1) carrying the same
+// timestamp, and the sender takes the difference from its clock as an RTT sample.
+// Timestamps are only read by the end that wrote them, so the clocks need not agree.
+// The HEALTH type is reserved on connections that use health checks.
+package health
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TypeHealth is the frame type of probes and echoes.
+const TypeHealth byte = 0xB0
+
+const (
+	kindProbe byte = 0
+	kindEcho  byte = 1
+)
+
+var ErrMalformed = errors.New("health: malformed HEALTH frame")
+
+// Checker answers the peer's probes and keeps a smoothed RTT from the echoes of its
+// own. It is safe for concurrent use.
+type Checker struct {
+	next  enproto.Handler
+	epoch time.Time // timestamps are nanoseconds since epoch, on the monotonic clock
+	now   func() time.Time
+
+	wmu sync.Mutex // serialises writes from Ping, echoes and WriteFrame
+	fw  *enproto.FrameWriter
+
+	mu     sync.Mutex
+	srtt   time.Duration
+	rttvar time.Duration
+	last   time.Duration
+}
+
+// New returns a Checker writing to fw and passing frames other than HEALTH to next.
+// Other goroutines writing to fw while the Checker is in use must go through
+// Checker.WriteFrame.
+func New(fw *enproto.FrameWriter, next enproto.Handler) *Checker {
+	return &Checker{next: next, fw: fw, epoch: time.Now(), now: time.Now}
+}
+
+// Ping sends a probe. Its echo updates RTT when it is served.
+func (c *Checker) Ping() error {
+	return c.send(kindProbe, uint64(c.now().Sub(c.epoch)))
+}
+
+// Run pings every interval until ctx is done or a write fails, returning the error.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := c.Ping(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// ServeFrame echoes probes, records the RTT of echoes and passes every other frame on.
+func (c *Checker) ServeFrame(msgType byte, payload []byte) error {
+	if msgType != TypeHealth {
+		return c.next.ServeFrame(msgType, payload)
+	}
+	if len(payload) != 9 {
+		return ErrMalformed
+	}
+	ts := binary.BigEndian.Uint64(payload[1:])
+
+	switch payload[0] {
+	case kindProbe:
+		return c.send(kindEcho, ts)
+	case kindEcho:
+		sent := time.Duration(ts)
+		rtt := c.now().Sub(c.epoch) - sent
+		if sent < 0 || rtt < 0 {
+			return ErrMalformed // not a timestamp of ours
+		}
+		c.sample(rtt)
+		return nil
+	}
+	return ErrMalformed
+}
+
+// sample folds an RTT measurement into the estimate as TCP does (RFC 6298).
+func (c *Checker) sample(rtt time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.last = rtt
+	if c.srtt == 0 {
+		c.srtt, c.rttvar = rtt, rtt/2
+		return
+	}
+	diff := c.srtt - rtt
+	if diff < 0 {
+		diff = -diff
+	}
+	c.rttvar = (3*c.rttvar + diff) / 4
+	c.srtt = (7*c.srtt + rtt) / 8
+}
+
+// RTT returns the smoothed round-trip time, or 0 before the first echo.
+func (c *Checker) RTT() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.srtt
+}
+
+// RTTVar returns the round-trip time variation, for timeouts of the form
+// RTT() + 4*RTTVar().
+func (c *Checker) RTTVar() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rttvar
+}
+
+// LastRTT returns the most recent RTT sample, or 0 before the first echo.
+func (c *Checker) LastRTT() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// WriteFrame writes and flushes a frame, serialised with the Checker's own writes.
+func (c *Checker) WriteFrame(msgType byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.fw.WriteFrame(msgType, payload)
+}
+
+func (c *Checker) send(kind byte, ts uint64) error {
+	var p [9]byte
+	p[0] = kind
+	binary.BigEndian.PutUint64(p[1:], ts)
+	return c.WriteFrame(TypeHealth, p[:])
+}
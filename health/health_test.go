@@ -0,0 +1,85 @@
+package health
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// serveAll reads every frame from buf into h.
+func serveAll(t *testing.T, buf *bytes.Buffer, h enproto.Handler) {
+	t.Helper()
+	r := enproto.NewFrameReader(buf)
+	for {
+		msgType, payload, err := r.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.ServeFrame(msgType, payload); err != nil {
+			t.Fatalf("ServeFrame: %v", err)
+		}
+	}
+}
+
+// TestChecker_RTT verifies probes are echoed and echoes produce a smoothed RTT.
+func TestChecker_RTT(t *testing.T) {
+	var toB, toA bytes.Buffer
+	var passed []byte
+	a := New(enproto.NewFrameWriter(&toB), enproto.HandlerFunc(func(byte, []byte) error { return nil }))
+	b := New(enproto.NewFrameWriter(&toA), enproto.HandlerFunc(func(msgType byte, _ []byte) error {
+		passed = append(passed, msgType)
+		return nil
+	}))
+
+	clock := time.Unix(0, 0)
+	a.epoch = clock
+	a.now = func() time.Time { return clock }
+
+	for _, rtt := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+		if err := a.Ping(); err != nil {
+			t.Fatal(err)
+		}
+		a.WriteFrame(0x01, []byte("data"))
+		serveAll(t, &toB, b) // b echoes
+		clock = clock.Add(rtt)
+		serveAll(t, &toA, a) // a measures
+	}
+
+	if got := a.LastRTT(); got != 200*time.Millisecond {
+		t.Errorf("LastRTT = %v; want 200ms", got)
+	}
+	// 100ms, then 7/8*100ms + 1/8*200ms.
+	if got := a.RTT(); got != 112500*time.Microsecond {
+		t.Errorf("RTT = %v; want 112.5ms", got)
+	}
+	if got := a.RTTVar(); got != 62500*time.Microsecond {
+		t.Errorf("RTTVar = %v; want 62.5ms", got)
+	}
+	if b.RTT() != 0 {
+		t.Error("echoing end recorded an RTT")
+	}
+	if len(passed) != 2 || passed[0] != 0x01 {
+		t.Errorf("passed on %v; want two 0x01 frames", passed)
+	}
+}
+
+// TestChecker_Malformed verifies bad HEALTH frames are rejected.
+func TestChecker_Malformed(t *testing.T) {
+	c := New(enproto.NewFrameWriter(&bytes.Buffer{}), nil)
+	for _, p := range [][]byte{
+		{kindProbe},
+		{7, 0, 0, 0, 0, 0, 0, 0, 0},
+		{kindEcho, 0x7F, 0, 0, 0, 0, 0, 0, 0}, // from the far future
+	} {
+		if err := c.ServeFrame(TypeHealth, p); !errors.Is(err, ErrMalformed) {
+			t.Errorf("ServeFrame(%x) = %v; want ErrMalformed", p, err)
+		}
+	}
+}
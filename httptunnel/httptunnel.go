@@ -0,0 +1,173 @@
+// Package httptunnel carries enproto connections through HTTP, for networks where
+// only HTTP egress is allowed.
+//
+// DialConnect tunnels through a forward proxy with the CONNECT method. DialUpgrade and
+// Handler establish the connection with an HTTP/1.1 Upgrade handshake to the
+// "enproto" protocol, which reverse proxies and load balancers pass through like
+// WebSocket upgrades. Either way the result is a plain net.Conn ready for
+// enproto.NewFramer.
+package httptunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Protocol is the token used in the Upgrade header.
+const Protocol = "enproto"
+
+// DialConnect connects to target through the HTTP proxy at proxyAddr using the
+// CONNECT method. header is sent with the request, for example to carry
+// Proxy-Authorization; it may be nil.
+func DialConnect(ctx context.Context, proxyAddr, target string, header http.Header) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: cloneHeader(header),
+	}
+	return handshake(ctx, proxyAddr, req, http.StatusOK)
+}
+
+// DialUpgrade connects to the HTTP server at rawURL and upgrades the connection to
+// the enproto protocol. Only http URLs are supported; header may be nil.
+func DialUpgrade(ctx context.Context, rawURL string, header http.Header) (net.Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" {
+		return nil, fmt.Errorf("httptunnel: unsupported scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "80")
+	}
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    u,
+		Host:   u.Host,
+		Header: cloneHeader(header),
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", Protocol)
+	return handshake(ctx, addr, req, http.StatusSwitchingProtocols)
+}
+
+// handshake dials addr, sends req and returns the connection if the response has
+// the wanted status.
+func handshake(ctx context.Context, addr string, req *http.Request, want int) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Abort the handshake if ctx ends while it is in progress.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != want {
+		conn.Close()
+		return nil, fmt.Errorf("httptunnel: %s %s: unexpected status %s", req.Method, req.Host, resp.Status)
+	}
+	if !stop() {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	return bufferedConn(conn, br), nil
+}
+
+// Handler returns an http.Handler that upgrades requests to the enproto protocol
+// and hands each upgraded connection to serve, which owns it from then on. Requests
+// that do not ask for the upgrade get 426 Upgrade Required.
+func Handler(serve func(net.Conn)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", Protocol) {
+			w.Header().Set("Upgrade", Protocol)
+			http.Error(w, "enproto upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + Protocol + "\r\n\r\n"
+		if _, err := rw.WriteString(resp); err != nil {
+			conn.Close()
+			return
+		}
+		if err := rw.Flush(); err != nil {
+			conn.Close()
+			return
+		}
+		serve(bufferedConn(conn, rw.Reader))
+	})
+}
+
+// headerContains reports whether a comma-separated header contains token,
+// ignoring case.
+func headerContains(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cloneHeader(h http.Header) http.Header {
+	if h == nil {
+		return make(http.Header)
+	}
+	return h.Clone()
+}
+
+// conn is a net.Conn whose first reads drain bytes already buffered during the
+// HTTP handshake.
+type conn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func bufferedConn(c net.Conn, br *bufio.Reader) net.Conn {
+	if br.Buffered() == 0 {
+		return c
+	}
+	return &conn{Conn: c, br: br}
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	if c.br != nil {
+		if c.br.Buffered() > 0 {
+			return c.br.Read(p)
+		}
+		c.br = nil
+	}
+	return c.Conn.Read(p)
+}
@@ -0,0 +1,143 @@
+package httptunnel
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// echo serves conn as an enproto echo peer.
+func echo(conn net.Conn) {
+	defer conn.Close()
+	fr := enproto.NewFramer(conn)
+	for {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			return
+		}
+		if err := fr.WriteFrame(msgType, payload); err != nil {
+			return
+		}
+	}
+}
+
+// roundTrip sends a frame over conn and checks it is echoed back.
+func roundTrip(t *testing.T, conn net.Conn) {
+	t.Helper()
+	fr := enproto.NewFramer(conn)
+	if err := fr.WriteFrame(0x1, []byte("tunnelled")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	_, payload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if string(payload) != "tunnelled" {
+		t.Errorf("payload = %q; want %q", payload, "tunnelled")
+	}
+}
+
+// listen accepts connections on a local port and serves each with serve.
+func listen(t *testing.T, serve func(net.Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(c)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// TestDialUpgrade verifies an enproto session over an upgraded HTTP connection.
+func TestDialUpgrade(t *testing.T) {
+	srv := httptest.NewServer(Handler(echo))
+	defer srv.Close()
+
+	conn, err := DialUpgrade(context.Background(), srv.URL+"/enproto", nil)
+	if err != nil {
+		t.Fatalf("DialUpgrade error: %v", err)
+	}
+	defer conn.Close()
+	roundTrip(t, conn)
+}
+
+// TestHandler_NoUpgrade ensures plain requests are answered with 426.
+func TestHandler_NoUpgrade(t *testing.T) {
+	srv := httptest.NewServer(Handler(echo))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("status = %d; want %d", resp.StatusCode, http.StatusUpgradeRequired)
+	}
+}
+
+// connectProxy is a minimal CONNECT proxy that requires the given authorization.
+func connectProxy(auth string) func(net.Conn) {
+	return func(client net.Conn) {
+		defer client.Close()
+		br := bufio.NewReader(client)
+		req, err := http.ReadRequest(br)
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		if req.Header.Get("Proxy-Authorization") != auth {
+			io.WriteString(client, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return
+		}
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			io.WriteString(client, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			return
+		}
+		defer upstream.Close()
+		io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n")
+
+		go io.Copy(upstream, br)
+		io.Copy(client, upstream)
+	}
+}
+
+// TestDialConnect verifies an enproto session through a CONNECT proxy.
+func TestDialConnect(t *testing.T) {
+	target := listen(t, echo)
+	proxy := listen(t, connectProxy("Basic c2VjcmV0"))
+
+	header := http.Header{"Proxy-Authorization": {"Basic c2VjcmV0"}}
+	conn, err := DialConnect(context.Background(), proxy, target, header)
+	if err != nil {
+		t.Fatalf("DialConnect error: %v", err)
+	}
+	defer conn.Close()
+	roundTrip(t, conn)
+}
+
+// TestDialConnect_Refused ensures a proxy error status is reported.
+func TestDialConnect_Refused(t *testing.T) {
+	target := listen(t, echo)
+	proxy := listen(t, connectProxy("Basic c2VjcmV0"))
+
+	_, err := DialConnect(context.Background(), proxy, target, nil)
+	if err == nil || !strings.Contains(err.Error(), "407") {
+		t.Errorf("expected 407 error, got %v", err)
+	}
+}
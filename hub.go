@@ -0,0 +1,216 @@
+package enproto
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrSlowConsumer is recorded on a HubConn evicted because its queue was full.
+	ErrSlowConsumer = errors.New("slow consumer")
+
+	// ErrRemovedFromHub is recorded on a HubConn removed by Hub.Remove or Hub.Close.
+	ErrRemovedFromHub = errors.New("removed from hub")
+)
+
+// SlowConsumerPolicy decides what a Hub does when a connection's queue is full.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDrop drops the frame for that connection only.
+	SlowConsumerDrop SlowConsumerPolicy = iota
+	// SlowConsumerEvict removes the connection from the Hub with ErrSlowConsumer.
+	SlowConsumerEvict
+)
+
+// Hub tracks a set of connections and broadcasts frames to them. Every connection
+// has its own bounded queue and writer goroutine, so one failing or slow peer never
+// blocks or breaks delivery to the others.
+type Hub struct {
+	queueSize int
+	policy    SlowConsumerPolicy
+
+	mu    sync.Mutex
+	conns map[*HubConn]struct{}
+}
+
+// NewHub returns a Hub that queues up to queueSize frames per connection and applies
+// policy to connections whose queue is full.
+func NewHub(queueSize int, policy SlowConsumerPolicy) *Hub {
+	return &Hub{
+		queueSize: queueSize,
+		policy:    policy,
+		conns:     make(map[*HubConn]struct{}),
+	}
+}
+
+// HubConn is a connection registered with a Hub.
+type HubConn struct {
+	hub   *Hub
+	fw    *FrameWriter
+	queue chan Frame
+	stop  chan struct{} // closed when removed from the hub
+	done  chan struct{} // closed when the writer goroutine exits
+
+	err error // why the connection left the hub; valid once done is closed
+}
+
+// Add registers fw with the hub and starts its writer goroutine. The hub becomes the
+// only writer of fw until the connection is removed.
+func (h *Hub) Add(fw *FrameWriter) *HubConn {
+	c := &HubConn{
+		hub:   h,
+		fw:    fw,
+		queue: make(chan Frame, h.queueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.run()
+	return c
+}
+
+// Remove unregisters c and waits for its writer goroutine to stop, so nothing more is
+// written to c's FrameWriter once it returns. A write already in progress is waited
+// out; frames still queued are discarded.
+func (h *Hub) Remove(c *HubConn) {
+	h.remove(c, ErrRemovedFromHub)
+	<-c.done
+}
+
+func (h *Hub) remove(c *HubConn, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+	delete(h.conns, c)
+	c.err = err
+	close(c.stop)
+}
+
+// Len returns the number of registered connections.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns)
+}
+
+// Broadcast queues a frame for every registered connection and returns how many
+// accepted it. The payload is shared by all queues and must not be modified afterwards.
+func (h *Hub) Broadcast(msgType byte, payload []byte) int {
+	return h.BroadcastFunc(msgType, payload, nil)
+}
+
+// BroadcastFunc is like Broadcast but only queues the frame for connections for which
+// match returns true. A nil match selects every connection. match is called without
+// the hub's lock held, so it may use the Hub.
+func (h *Hub) BroadcastFunc(msgType byte, payload []byte, match func(*HubConn) bool) int {
+	frame := Frame{Type: msgType, Payload: payload}
+
+	targets := h.snapshot()
+	if match != nil {
+		selected := targets[:0]
+		for _, c := range targets {
+			if match(c) {
+				selected = append(selected, c)
+			}
+		}
+		targets = selected
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var queued int
+	for _, c := range targets {
+		if _, ok := h.conns[c]; !ok {
+			continue // removed while match ran
+		}
+		select {
+		case c.queue <- frame:
+			queued++
+		default:
+			if h.policy == SlowConsumerEvict {
+				delete(h.conns, c)
+				c.err = ErrSlowConsumer
+				close(c.stop)
+			}
+		}
+	}
+	return queued
+}
+
+// Close removes every connection from the hub and waits for their writer goroutines
+// to stop.
+func (h *Hub) Close() {
+	conns := h.snapshot()
+	for _, c := range conns {
+		h.remove(c, ErrRemovedFromHub)
+	}
+	for _, c := range conns {
+		<-c.done
+	}
+}
+
+// snapshot returns the registered connections.
+func (h *Hub) snapshot() []*HubConn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conns := make([]*HubConn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// run writes queued frames, flushing whenever the queue drains. It stops without
+// writing once the connection is removed, even with frames still queued.
+func (c *HubConn) run() {
+	defer close(c.done)
+
+	for {
+		select {
+		case frame := <-c.queue:
+			select {
+			case <-c.stop:
+				return // the queue and stop were both ready; removal wins
+			default:
+			}
+			if err := c.fw.WriteFrameBuffered(frame.Type, frame.Payload); err != nil {
+				c.hub.remove(c, err)
+				return
+			}
+			if len(c.queue) == 0 {
+				if err := c.fw.Flush(); err != nil {
+					c.hub.remove(c, err)
+					return
+				}
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Done returns a channel closed once the connection has left the hub and its writer
+// goroutine has stopped.
+func (c *HubConn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection left the hub: its write error, ErrSlowConsumer, or
+// ErrRemovedFromHub. It returns nil while the connection is active.
+func (c *HubConn) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,196 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe for a hub writer goroutine and the test to share.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// failWriter fails every write.
+type failWriter struct{ err error }
+
+func (w failWriter) Write([]byte) (int, error) { return 0, w.err }
+
+// blockWriter blocks every write until release is closed.
+type blockWriter struct{ release chan struct{} }
+
+func (w blockWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+// TestHub_Broadcast verifies frames reach every connection, or only the matching ones.
+func TestHub_Broadcast(t *testing.T) {
+	h := NewHub(4, SlowConsumerDrop)
+	a, b := &lockedBuffer{}, &lockedBuffer{}
+	ca := h.Add(NewFrameWriter(a))
+	h.Add(NewFrameWriter(b))
+
+	if n := h.Broadcast(0x1, []byte("all")); n != 2 {
+		t.Errorf("Broadcast queued %d; want 2", n)
+	}
+	only := func(c *HubConn) bool { return c == ca }
+	if n := h.BroadcastFunc(0x2, []byte("only a"), only); n != 1 {
+		t.Errorf("BroadcastFunc queued %d; want 1", n)
+	}
+
+	h.Close()
+	<-ca.Done()
+	if err := ca.Err(); !errors.Is(err, ErrRemovedFromHub) {
+		t.Errorf("expected ErrRemovedFromHub after Close, got %v", err)
+	}
+	if n := h.Len(); n != 0 {
+		t.Errorf("Len after Close = %d; want 0", n)
+	}
+}
+
+// TestHub_Delivery verifies queued frames are written and flushed to the connection.
+func TestHub_Delivery(t *testing.T) {
+	h := NewHub(4, SlowConsumerDrop)
+	r, w := io.Pipe()
+	h.Add(NewFrameWriter(w))
+
+	h.Broadcast(0x1, []byte("first"))
+	h.Broadcast(0x2, []byte("second"))
+
+	fr := NewFrameReader(r)
+	for _, want := range []string{"first", "second"} {
+		_, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame error: %v", err)
+		}
+		if string(payload) != want {
+			t.Errorf("payload = %q; want %q", payload, want)
+		}
+	}
+	h.Close()
+}
+
+// TestHub_WriteErrorIsolation ensures a failing connection is dropped without affecting others.
+func TestHub_WriteErrorIsolation(t *testing.T) {
+	h := NewHub(4, SlowConsumerDrop)
+	errBroken := errors.New("broken pipe")
+	bad := h.Add(NewFrameWriter(failWriter{errBroken}))
+	good := h.Add(NewFrameWriter(&lockedBuffer{}))
+
+	h.Broadcast(0x1, []byte("payload"))
+
+	<-bad.Done()
+	if err := bad.Err(); !errors.Is(err, errBroken) {
+		t.Errorf("expected write error, got %v", err)
+	}
+	if err := good.Err(); err != nil {
+		t.Errorf("expected healthy connection, got %v", err)
+	}
+	if n := h.Len(); n != 1 {
+		t.Errorf("Len = %d; want 1", n)
+	}
+	h.Close()
+}
+
+// TestHub_SlowConsumerEvict ensures a connection with a full queue is evicted.
+func TestHub_SlowConsumerEvict(t *testing.T) {
+	h := NewHub(1, SlowConsumerEvict)
+	release := make(chan struct{})
+	defer close(release)
+	slow := h.Add(NewFrameWriter(blockWriter{release}, WithWriteBufferSize(16)))
+
+	// The writer goroutine blocks on the first large frame, the second fills the
+	// queue and the third overflows it.
+	payload := make([]byte, 64)
+	for i := 0; i < 3; i++ {
+		h.Broadcast(0x1, payload)
+	}
+
+	<-slow.stop
+	if n := h.Len(); n != 0 {
+		t.Errorf("Len after eviction = %d; want 0", n)
+	}
+	if n := h.Broadcast(0x1, payload); n != 0 {
+		t.Errorf("Broadcast after eviction queued %d; want 0", n)
+	}
+}
+
+// gatedWriter blocks writes until release is closed and counts the bytes written.
+// entered is closed when the first write starts.
+type gatedWriter struct {
+	entered chan struct{}
+	once    sync.Once
+	release chan struct{}
+	n       atomic.Int64
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.entered) })
+	<-w.release
+	w.n.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// TestHub_Remove ensures Remove waits out a write in progress and nothing queued is
+// written after it returns.
+func TestHub_Remove(t *testing.T) {
+	h := NewHub(4, SlowConsumerDrop)
+	w := &gatedWriter{entered: make(chan struct{}), release: make(chan struct{})}
+	c := h.Add(NewFrameWriter(w, WithWriteBufferSize(16)))
+
+	payload := make([]byte, 64)
+	for i := 0; i < 4; i++ {
+		h.Broadcast(0x1, payload)
+	}
+	<-w.entered
+
+	removed := make(chan struct{})
+	go func() {
+		h.Remove(c)
+		close(removed)
+	}()
+	select {
+	case <-removed:
+		t.Fatal("Remove returned while a write was in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(w.release)
+	<-removed
+	if n := w.n.Load(); n > HeaderSize+int64(len(payload)) {
+		t.Errorf("%d bytes written; want at most the frame in progress", n)
+	}
+	if err := c.Err(); !errors.Is(err, ErrRemovedFromHub) {
+		t.Errorf("Err = %v; want ErrRemovedFromHub", err)
+	}
+}
+
+// TestHub_MatchUsesHub ensures a BroadcastFunc match may call back into the Hub.
+func TestHub_MatchUsesHub(t *testing.T) {
+	h := NewHub(4, SlowConsumerDrop)
+	defer h.Close()
+	h.Add(NewFrameWriter(&lockedBuffer{}))
+
+	match := func(*HubConn) bool { return h.Len() == 1 }
+	if n := h.BroadcastFunc(0x1, []byte("x"), match); n != 1 {
+		t.Errorf("BroadcastFunc queued %d; want 1", n)
+	}
+}
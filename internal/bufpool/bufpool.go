@@ -0,0 +1,73 @@
+// Package bufpool is a size-classed byte buffer pool shared by the read and write
+// paths of enproto and its subpackages.
+//
+// Buffers come in classes of 1 KiB, 16 KiB and 256 KiB, plus a class for anything
+// larger. Get rounds a request up to the smallest class that fits; buffers in the
+// largest class have whatever capacity they were first allocated with.
+package bufpool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// classSizes are the capacities of the fixed size classes, smallest first.
+var classSizes = [...]int{1 << 10, 16 << 10, 256 << 10}
+
+var (
+	classes [len(classSizes) + 1]sync.Pool // the last one holds oversized buffers
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+)
+
+// class returns the index of the smallest class that holds n bytes.
+func class(n int) int {
+	for i, size := range classSizes {
+		if n <= size {
+			return i
+		}
+	}
+	return len(classSizes)
+}
+
+// Get returns a buffer of length n from the pool, allocating one if none is free.
+// Its contents are unspecified.
+func Get(n int) []byte {
+	c := class(n)
+	if p, ok := classes[c].Get().(*[]byte); ok {
+		if cap(*p) >= n {
+			hits.Add(1)
+			return (*p)[:n]
+		}
+		// An oversized buffer too small for this request; let it go.
+	}
+	misses.Add(1)
+
+	size := n
+	if c < len(classSizes) {
+		size = classSizes[c]
+	}
+	return make([]byte, n, size)
+}
+
+// Put returns a buffer obtained from Get to the pool. Buffers whose capacity does
+// not match a size class, including ones not obtained from Get, are dropped.
+func Put(b []byte) {
+	c := class(cap(b))
+	if c < len(classSizes) && cap(b) != classSizes[c] {
+		return
+	}
+	b = b[:cap(b)]
+	classes[c].Put(&b)
+}
+
+// Stats counts Get calls served from the pool (hits) and by allocating (misses).
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// ReadStats returns the pool's counters since the process started.
+func ReadStats() Stats {
+	return Stats{Hits: hits.Load(), Misses: misses.Load()}
+}
@@ -0,0 +1,52 @@
+package bufpool
+
+import "testing"
+
+// TestGet_SizeClasses verifies requests are rounded up to their size class.
+func TestGet_SizeClasses(t *testing.T) {
+	tests := []struct{ n, wantCap int }{
+		{0, 1 << 10},
+		{1 << 10, 1 << 10},
+		{1<<10 + 1, 16 << 10},
+		{200 << 10, 256 << 10},
+		{1 << 20, 1 << 20},
+	}
+	for _, tt := range tests {
+		b := Get(tt.n)
+		if len(b) != tt.n || cap(b) < tt.wantCap {
+			t.Errorf("Get(%d): len %d cap %d; want len %d cap >= %d", tt.n, len(b), cap(b), tt.n, tt.wantCap)
+		}
+	}
+}
+
+// TestPut_Reuse verifies released buffers are reused and counted as hits.
+func TestPut_Reuse(t *testing.T) {
+	before := ReadStats()
+
+	// sync.Pool may drop buffers at any time, so only require some reuse.
+	var reused bool
+	for i := 0; i < 10 && !reused; i++ {
+		b := Get(100)
+		b[0] = 0xAB
+		Put(b)
+		again := Get(100)
+		reused = cap(again) == cap(b) && &again[:1][0] == &b[:1][0]
+		Put(again)
+	}
+	if !reused {
+		t.Skip("pool did not retain buffers in this run")
+	}
+
+	after := ReadStats()
+	if after.Hits <= before.Hits {
+		t.Errorf("hits = %d after reuse; want more than %d", after.Hits, before.Hits)
+	}
+}
+
+// TestPut_Foreign ensures buffers that match no class are not pooled.
+func TestPut_Foreign(t *testing.T) {
+	Put(make([]byte, 100)) // capacity 100 is not a class size
+	if b := Get(100); cap(b) == 100 {
+		t.Errorf("foreign buffer was handed out")
+	}
+}
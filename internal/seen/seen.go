@@ -0,0 +1,43 @@
+// Package seen is a set of recently seen keys, shared by the duplicate and loop
+// suppression of enproto and its subpackages.
+package seen
+
+import (
+	"sync"
+	"time"
+)
+
+// Set remembers keys for TTL after they are added. Expired keys are swept out at most
+// once per TTL, as keys are added. The zero value is an empty set that forgets keys
+// immediately; set TTL before use. It is safe for concurrent use.
+type Set[K comparable] struct {
+	TTL time.Duration
+
+	mu        sync.Mutex
+	keys      map[K]time.Time
+	lastPrune time.Time
+}
+
+// Add records key as seen at now and reports whether it was not already present.
+func (s *Set[K]) Add(key K, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys == nil {
+		s.keys = make(map[K]time.Time)
+	}
+	if now.Sub(s.lastPrune) >= s.TTL {
+		for k, at := range s.keys {
+			if now.Sub(at) >= s.TTL {
+				delete(s.keys, k)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	if at, ok := s.keys[key]; ok && now.Sub(at) < s.TTL {
+		return false
+	}
+	s.keys[key] = now
+	return true
+}
@@ -0,0 +1,35 @@
+package seen
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSet_Add verifies keys are suppressed until their TTL expires.
+func TestSet_Add(t *testing.T) {
+	s := Set[string]{TTL: time.Minute}
+	now := time.Now()
+
+	if !s.Add("a", now) {
+		t.Errorf("first add reported a duplicate")
+	}
+	if s.Add("a", now.Add(time.Second)) {
+		t.Errorf("repeat add within TTL was not suppressed")
+	}
+	if !s.Add("a", now.Add(2*time.Minute)) {
+		t.Errorf("add after TTL was suppressed")
+	}
+}
+
+// TestSet_Prune verifies expired keys are swept out as new ones are added.
+func TestSet_Prune(t *testing.T) {
+	s := Set[int]{TTL: time.Minute}
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		s.Add(i, now)
+	}
+	s.Add(100, now.Add(2*time.Minute))
+	if n := len(s.keys); n != 1 {
+		t.Errorf("%d keys held after the TTL; want 1", n)
+	}
+}
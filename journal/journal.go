@@ -0,0 +1,150 @@
+// Package journal records frames in an append-only, tamper-evident log for audit
+// and compliance.
+//
+// A journal is itself an enproto stream of ENTRY frames, each
+// [8B sequence][8B Unix time in ns][32B hash of the previous entry][1B frame type][payload],
+// big-endian, where an entry's hash is the SHA-256 of its ENTRY payload and the first
+// entry's previous hash is zero. Changing, removing or reordering any entry breaks the
+// chain from that point on, which Verify reports. Truncating the tail cannot be seen
+// from the journal alone: publish the Head hash somewhere the journal's writer cannot
+// change it to detect that too.
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TypeEntry is the frame type of journal entries.
+const TypeEntry byte = 0x01
+
+// entryHeaderSize is the size of an entry before the recorded payload.
+const entryHeaderSize = 8 + 8 + sha256.Size + 1
+
+var (
+	ErrMalformed = errors.New("journal: malformed entry")
+
+	// ErrTampered is wrapped by Verify errors for entries that break the chain.
+	ErrTampered = errors.New("journal: chain broken")
+)
+
+// Hash identifies a journal entry and, through the chain, everything before it.
+type Hash [sha256.Size]byte
+
+// Entry is one recorded frame.
+type Entry struct {
+	Seq     uint64
+	Time    time.Time
+	Prev    Hash // hash of the previous entry
+	Hash    Hash // hash of this entry
+	Type    byte
+	Payload []byte
+}
+
+// Writer appends entries to a journal. It is safe for concurrent use.
+type Writer struct {
+	mu   sync.Mutex
+	fw   *enproto.FrameWriter
+	seq  uint64
+	head Hash
+	now  func() time.Time
+}
+
+// NewWriter starts a new journal on w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{fw: enproto.NewFrameWriter(w), now: time.Now}
+}
+
+// Resume verifies the existing journal read from r and returns a Writer appending to
+// w after its last entry, for example with both the same file opened for appending.
+func Resume(w io.Writer, r io.Reader) (*Writer, error) {
+	head, n, err := Verify(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	jw := NewWriter(w)
+	jw.seq, jw.head = n, head
+	return jw, nil
+}
+
+// Record appends an entry for a frame and flushes it.
+func (j *Writer) Record(msgType byte, payload []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e := make([]byte, entryHeaderSize, entryHeaderSize+len(payload))
+	binary.BigEndian.PutUint64(e[0:], j.seq)
+	binary.BigEndian.PutUint64(e[8:], uint64(j.now().UnixNano()))
+	copy(e[16:], j.head[:])
+	e[16+sha256.Size] = msgType
+	e = append(e, payload...)
+
+	if err := j.fw.WriteFrame(TypeEntry, e); err != nil {
+		return err
+	}
+	j.seq++
+	j.head = sha256.Sum256(e)
+	return nil
+}
+
+// Head returns the hash of the last entry written, or zero for an empty journal.
+func (j *Writer) Head() Hash {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.head
+}
+
+// Handler returns a Handler that records every frame and then passes it to h.
+// Frames are not passed on if recording fails.
+func (j *Writer) Handler(h enproto.Handler) enproto.Handler {
+	return enproto.HandlerFunc(func(msgType byte, payload []byte) error {
+		if err := j.Record(msgType, payload); err != nil {
+			return err
+		}
+		return h.ServeFrame(msgType, payload)
+	})
+}
+
+// Verify reads a journal from r and checks its chain, calling fn, if not nil, with
+// each entry in order. It returns the hash of the last entry and the number of
+// entries. An error from fn stops verification and is returned.
+func Verify(r io.Reader, fn func(Entry) error) (head Hash, n uint64, err error) {
+	fr := enproto.NewFrameReader(r)
+	for ; ; n++ {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return head, n, nil
+			}
+			return head, n, fmt.Errorf("journal: entry %d: %w", n, err)
+		}
+		if msgType != TypeEntry || len(payload) < entryHeaderSize {
+			return head, n, fmt.Errorf("%w: entry %d", ErrMalformed, n)
+		}
+
+		e := Entry{
+			Seq:     binary.BigEndian.Uint64(payload[0:]),
+			Time:    time.Unix(0, int64(binary.BigEndian.Uint64(payload[8:]))),
+			Prev:    Hash(payload[16 : 16+sha256.Size]),
+			Hash:    sha256.Sum256(payload),
+			Type:    payload[16+sha256.Size],
+			Payload: payload[entryHeaderSize:],
+		}
+		if e.Seq != n || e.Prev != head {
+			return head, n, fmt.Errorf("%w: at entry %d", ErrTampered, n)
+		}
+		if fn != nil {
+			if err := fn(e); err != nil {
+				return head, n, err
+			}
+		}
+		head = e.Hash
+	}
+}
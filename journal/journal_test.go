@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// record writes frames to a new journal and returns its bytes and head.
+func record(t *testing.T, payloads ...string) ([]byte, Hash) {
+	t.Helper()
+	var buf bytes.Buffer
+	j := NewWriter(&buf)
+	for _, p := range payloads {
+		if err := j.Record(0x10, []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes(), j.Head()
+}
+
+// TestVerify verifies an untouched journal checks out entry by entry.
+func TestVerify(t *testing.T) {
+	data, head := record(t, "login", "transfer", "logout")
+
+	var got []string
+	gotHead, n, err := Verify(bytes.NewReader(data), func(e Entry) error {
+		if e.Type != 0x10 || time.Since(e.Time) > time.Minute {
+			t.Errorf("entry %d: type %d at %v", e.Seq, e.Type, e.Time)
+		}
+		got = append(got, string(e.Payload))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || gotHead != head {
+		t.Errorf("Verify = %d entries, head %x; want 3, %x", n, gotHead, head)
+	}
+	if len(got) != 3 || got[0] != "login" || got[2] != "logout" {
+		t.Errorf("entries = %q", got)
+	}
+}
+
+// TestVerify_Tampered verifies edited, dropped and reordered entries are detected.
+func TestVerify_Tampered(t *testing.T) {
+	data, _ := record(t, "login", "transfer 100", "logout")
+	first := enproto.HeaderSize + entryHeaderSize + len("login")
+	second := enproto.HeaderSize + entryHeaderSize + len("transfer 100")
+
+	edited := bytes.Clone(data)
+	edited[first+second-1] = '9' // "transfer 109"
+
+	dropped := append(bytes.Clone(data[:first]), data[first+second:]...)
+	reordered := append(bytes.Clone(data[first:first+second]), data[:first]...)
+
+	for name, d := range map[string][]byte{"edited": edited, "dropped": dropped, "reordered": reordered} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Verify(bytes.NewReader(d), nil); !errors.Is(err, ErrTampered) {
+				t.Errorf("got %v; want ErrTampered", err)
+			}
+		})
+	}
+}
+
+// TestResume verifies a resumed journal continues the same chain.
+func TestResume(t *testing.T) {
+	data, head := record(t, "login")
+
+	var more bytes.Buffer
+	j, err := Resume(&more, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := enproto.NewRouter()
+	rt.HandleDefault(j.Handler(enproto.HandlerFunc(func(byte, []byte) error { return nil })))
+	if err := rt.Dispatch(0x11, []byte("logout")); err != nil {
+		t.Fatal(err)
+	}
+
+	all := append(bytes.Clone(data), more.Bytes()...)
+	gotHead, n, err := Verify(bytes.NewReader(all), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || gotHead != j.Head() || gotHead == head {
+		t.Errorf("Verify = %d entries, head %x; want 2, %x", n, gotHead, j.Head())
+	}
+}
@@ -0,0 +1,86 @@
+//go:build go1.24
+
+package kex
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/mlkem"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Hybrid exchange frame types.
+const (
+	// TypeHybrid carries [32B X25519 public key][1184B ML-KEM-768 encapsulation key].
+	TypeHybrid byte = 0xC1
+	// TypeHybridCiphertext carries [1088B ML-KEM-768 ciphertext] encapsulated to the
+	// peer's key.
+	TypeHybridCiphertext byte = 0xC2
+)
+
+const hybridTranscriptLabel = "enproto kex hybrid v1"
+
+// ExchangeHybrid is Exchange combined with ML-KEM-768, so that the keys stay secret
+// unless both X25519 and ML-KEM are broken, including by a future quantum computer
+// decrypting a recording of the session.
+//
+// Each end sends a HYBRID frame with fresh X25519 and ML-KEM keys, then a CIPHERTEXT
+// frame encapsulating a secret to the peer's ML-KEM key. The X25519 secret and both
+// encapsulated secrets feed the key derivation, and every frame is covered by the
+// transcript. Both ends must call ExchangeHybrid; an end calling Exchange fails with
+// ErrUnexpectedFrame. Like Exchange, it waits for its own frames to be written even
+// when a read fails. It needs Go 1.24 or later.
+func ExchangeHybrid(f *enproto.Framer, context []byte) (*Keys, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	dk, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, err
+	}
+	local := append(priv.PublicKey().Bytes(), dk.EncapsulationKey().Bytes()...)
+
+	remote, err := swap(f, TypeHybrid, local)
+	if err != nil {
+		return nil, err
+	}
+	if len(remote) != 32+mlkem.EncapsulationKeySize768 {
+		return nil, ErrMalformed
+	}
+	if bytes.Equal(local, remote) {
+		return nil, ErrReflected
+	}
+	secret, err := x25519(priv, remote[:32])
+	if err != nil {
+		return nil, err
+	}
+	ek, err := mlkem.NewEncapsulationKey768(remote[32:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	sent, ct := ek.Encapsulate()
+	peerCT, err := swap(f, TypeHybridCiphertext, ct)
+	if err != nil {
+		return nil, err
+	}
+	received, err := dk.Decapsulate(peerCT)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	// Order the encapsulated secrets and ciphertexts by the end that sent them, as
+	// derive orders the HYBRID frames.
+	ctFirst, ctSecond := ct, peerCT
+	if bytes.Compare(local, remote) > 0 {
+		ctFirst, ctSecond = peerCT, ct
+		sent, received = received, sent
+	}
+	secret = append(secret, sent...)
+	secret = append(secret, received...)
+	return derive(hybridTranscriptLabel, secret, local, remote, ctFirst, ctSecond, context), nil
+}
@@ -0,0 +1,40 @@
+//go:build go1.24
+
+package kex
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestExchangeHybrid verifies both ends derive matching keys, distinct from each
+// exchange to the next, and that context is bound in.
+func TestExchangeHybrid(t *testing.T) {
+	a, b := exchangePairWith(t, ExchangeHybrid, []byte("v1"), []byte("v1"))
+	if a.Send != b.Receive || a.Receive != b.Send || a.Transcript != b.Transcript {
+		t.Error("keys do not match across ends")
+	}
+	if a.Send == a.Receive {
+		t.Error("both directions share one key")
+	}
+
+	c, d := exchangePairWith(t, ExchangeHybrid, []byte("v1"), []byte("v2"))
+	if c.Send == d.Receive || c.Send == a.Send {
+		t.Error("keys agree despite different context")
+	}
+}
+
+// TestExchangeHybrid_ClassicPeer verifies a peer running the plain exchange is refused.
+func TestExchangeHybrid_ClassicPeer(t *testing.T) {
+	ca, cb := net.Pipe()
+	defer ca.Close()
+	defer cb.Close()
+
+	go Exchange(enproto.NewFramer(cb), nil)
+	if _, err := ExchangeHybrid(enproto.NewFramer(ca), nil); !errors.Is(err, ErrUnexpectedFrame) {
+		t.Errorf("got %v; want ErrUnexpectedFrame", err)
+	}
+}
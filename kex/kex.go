@@ -0,0 +1,242 @@
+// Package kex derives per-session keys with an ephemeral X25519 key exchange, for
+// links that need a shared secret without a full handshake protocol such as Noise
+// or TLS.
+//
+// Each end sends one KEX frame carrying a fresh public key, [32B X25519 public key],
+// and reads the peer's. The shared secret is expanded with HKDF-SHA256 into a key and
+// IV per direction, salted by a transcript hash over both public keys and any context
+// the caller binds in, such as a Transcript of earlier frames, so the two ends only
+// agree on keys if they saw the same exchange. The key exchange is unauthenticated:
+// bind it to an identity, for example by signing Keys.Transcript, before trusting the
+// keys against an active attacker.
+//
+// The KEX frame type is reserved on connections that use the exchange.
+package kex
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TypeKEX is the frame type carrying a public key.
+const TypeKEX byte = 0xC0
+
+// Sizes of the derived keys and IVs.
+const (
+	KeySize = 32
+	IVSize  = 12
+)
+
+var (
+	ErrMalformed = errors.New("kex: malformed KEX frame")
+
+	// ErrUnexpectedFrame is returned when the peer sends another frame before its key.
+	ErrUnexpectedFrame = errors.New("kex: unexpected frame")
+
+	// ErrReflected is returned when the peer echoes this end's own public key.
+	ErrReflected = errors.New("kex: peer sent our own public key")
+
+	// ErrExportTooLong is returned by ExportKeyingMaterial for lengths HKDF cannot
+	// produce.
+	ErrExportTooLong = errors.New("kex: keying material too long")
+)
+
+// transcriptLabel starts every transcript, separating it from other uses of SHA-256.
+const transcriptLabel = "enproto kex v1"
+
+// Keys are the result of an exchange.
+type Keys struct {
+	// Send encrypts or authenticates frames to the peer; it is the peer's Receive.
+	Send [KeySize]byte
+	// Receive is the peer's Send.
+	Receive [KeySize]byte
+	// SendIV and ReceiveIV are per-direction nonce bases for an AEAD keyed with Send
+	// and Receive, such as AES-GCM.
+	SendIV    [IVSize]byte
+	ReceiveIV [IVSize]byte
+
+	exporter []byte // secret behind ExportKeyingMaterial
+	// Transcript identifies the exchange and is the same on both ends. Higher layers
+	// can sign it or bind tokens to it.
+	Transcript [sha256.Size]byte
+}
+
+// Exchange runs the key exchange over f: it sends a KEX frame with a fresh public
+// key, reads the peer's, and derives the session keys. context is bound into the
+// transcript, so frames negotiated before the exchange can be covered by passing
+// them here; both ends must pass the same bytes. Both ends call Exchange; neither
+// needs to know which side it is.
+//
+// Exchange waits for its KEX frame to be written even if reading the peer's fails,
+// so a peer that stops reading can hold it up; set a deadline on the transport to
+// bound the exchange.
+func Exchange(f *enproto.Framer, context []byte) (*Keys, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	local := priv.PublicKey().Bytes()
+
+	remote, err := swap(f, TypeKEX, local)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(local, remote) {
+		return nil, ErrReflected
+	}
+	secret, err := x25519(priv, remote)
+	if err != nil {
+		return nil, err
+	}
+	return derive(transcriptLabel, secret, local, remote, context), nil
+}
+
+// swap sends a frame of msgType and reads the peer's frame of the same type. It
+// writes while reading, so that two ends over an unbuffered transport do not block
+// on each other's write. It returns only once the write has finished, even when the
+// read fails, so the caller never shares the FrameWriter with a write in flight.
+func swap(f *enproto.Framer, msgType byte, local []byte) ([]byte, error) {
+	werr := make(chan error, 1)
+	go func() { werr <- f.WriteFrame(msgType, local) }()
+
+	t, remote, err := f.ReadFrame()
+	writeErr := <-werr
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t != msgType {
+		return nil, fmt.Errorf("%w: type %d, expected %d", ErrUnexpectedFrame, t, msgType)
+	}
+	return remote, nil
+}
+
+// x25519 computes the shared secret with the peer's public key.
+func x25519(priv *ecdh.PrivateKey, remote []byte) ([]byte, error) {
+	peer, err := ecdh.X25519().NewPublicKey(remote)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	secret, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return secret, nil
+}
+
+// derive computes the Keys from the shared secret and the frames each end sent:
+// local and remote identify the two ends, and extra is hashed after them.
+func derive(label string, secret, local, remote []byte, extra ...[]byte) *Keys {
+	// The ends are ordered so that both hash the same transcript.
+	first, second := local, remote
+	if bytes.Compare(first, second) > 0 {
+		first, second = second, first
+	}
+	h := sha256.New()
+	h.Write([]byte(label))
+	h.Write(first)
+	h.Write(second)
+	for _, b := range extra {
+		h.Write(b)
+	}
+
+	var k Keys
+	h.Sum(k.Transcript[:0])
+
+	prk := hkdf(k.Transcript[:], secret)
+	// Each direction's secrets are labelled with its sender's first frame.
+	copy(k.Send[:], expand(prk, "key", local))
+	copy(k.Receive[:], expand(prk, "key", remote))
+	copy(k.SendIV[:], expand(prk, "iv", local))
+	copy(k.ReceiveIV[:], expand(prk, "iv", remote))
+	k.exporter = expand(prk, "exporter", nil)
+	return &k
+}
+
+// ExportKeyingMaterial returns length bytes of keying material unique to this
+// session, label and context, the same on both ends, in the manner of TLS exporters
+// (RFC 5705). Higher layers can use it to bind their own tokens to the session.
+// Exported material reveals nothing about the session keys.
+func (k *Keys) ExportKeyingMaterial(label string, context []byte, length int) ([]byte, error) {
+	if length < 0 || length > 255*sha256.Size {
+		return nil, ErrExportTooLong
+	}
+
+	// A per-label secret, then HKDF-Expand over the context for as many blocks as needed.
+	secret := expand(k.exporter, "label", append([]byte(label), 0))
+	out := make([]byte, 0, length+sha256.Size)
+	var block []byte
+	for i := byte(1); len(out) < length; i++ {
+		m := hmac.New(sha256.New, secret)
+		m.Write(block)
+		m.Write(context)
+		m.Write([]byte{i})
+		block = m.Sum(nil)
+		out = append(out, block...)
+	}
+	return out[:length], nil
+}
+
+// Wipe overwrites the keys, IVs and exporter secret with zeros once the session is
+// over, so they do not linger in memory. The Keys must not be used afterwards.
+func (k *Keys) Wipe() {
+	clear(k.exporter)
+	*k = Keys{}
+}
+
+// Transcript is a running hash of the frames exchanged before a key exchange, such
+// as a version or capability negotiation. Passing its Sum to Exchange as the context
+// binds every one of those frames into the keys, so the ends only agree on keys if
+// they agree on everything negotiated. Both ends must add the same frames in the
+// same order, whichever end sent them.
+type Transcript struct {
+	h hash.Hash
+}
+
+// NewTranscript returns an empty Transcript.
+func NewTranscript() *Transcript {
+	return &Transcript{h: sha256.New()}
+}
+
+// Add appends a frame to the transcript.
+func (t *Transcript) Add(msgType byte, payload []byte) {
+	// Length-prefixing keeps frame boundaries unambiguous.
+	var hdr [5]byte
+	hdr[0] = msgType
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
+	t.h.Write(hdr[:])
+	t.h.Write(payload)
+}
+
+// Sum returns the hash of the frames added so far.
+func (t *Transcript) Sum() []byte {
+	return t.h.Sum(nil)
+}
+
+// hkdf is HKDF-Extract with SHA-256 (RFC 5869).
+func hkdf(salt, secret []byte) []byte {
+	m := hmac.New(sha256.New, salt)
+	m.Write(secret)
+	return m.Sum(nil)
+}
+
+// expand is HKDF-Expand with SHA-256 for a single 32-byte block, with the info
+// string made of label and data.
+func expand(prk []byte, label string, data []byte) []byte {
+	m := hmac.New(sha256.New, prk)
+	m.Write([]byte(label))
+	m.Write(data)
+	m.Write([]byte{1})
+	return m.Sum(nil)
+}
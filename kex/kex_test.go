@@ -0,0 +1,191 @@
+package kex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// exchangePair runs Exchange on both ends of a pipe with the given contexts.
+func exchangePair(t *testing.T, ctxA, ctxB []byte) (a, b *Keys) {
+	t.Helper()
+	return exchangePairWith(t, Exchange, ctxA, ctxB)
+}
+
+// exchangePairWith runs exchange on both ends of a pipe with the given contexts.
+func exchangePairWith(t *testing.T, exchange func(*enproto.Framer, []byte) (*Keys, error), ctxA, ctxB []byte) (a, b *Keys) {
+	t.Helper()
+	ca, cb := net.Pipe()
+	defer ca.Close()
+	defer cb.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		b, err = exchange(enproto.NewFramer(cb), ctxB)
+		errc <- err
+	}()
+	a, err := exchange(enproto.NewFramer(ca), ctxA)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("peer Exchange: %v", err)
+	}
+	return a, b
+}
+
+// TestExchange verifies both ends derive matching keys and transcript.
+func TestExchange(t *testing.T) {
+	a, b := exchangePair(t, []byte("v1"), []byte("v1"))
+	if a.Send != b.Receive || a.Receive != b.Send {
+		t.Error("send and receive keys do not match across ends")
+	}
+	if a.Send == a.Receive {
+		t.Error("both directions share one key")
+	}
+	if a.Transcript != b.Transcript {
+		t.Error("transcripts differ")
+	}
+
+	c, _ := exchangePair(t, []byte("v1"), []byte("v1"))
+	if c.Send == a.Send {
+		t.Error("two exchanges derived the same key")
+	}
+}
+
+// TestExchange_ContextMismatch verifies differing bound context yields different keys.
+func TestExchange_ContextMismatch(t *testing.T) {
+	a, b := exchangePair(t, []byte("v1"), []byte("v2"))
+	if a.Transcript == b.Transcript || a.Send == b.Receive {
+		t.Error("keys agree despite different context")
+	}
+}
+
+// TestExchange_BadPeer verifies malformed, unexpected and reflected frames fail.
+func TestExchange_BadPeer(t *testing.T) {
+	tests := []struct {
+		name    string
+		msgType byte
+		payload func(own []byte) []byte
+		want    error
+	}{
+		{"short key", TypeKEX, func([]byte) []byte { return []byte{1, 2, 3} }, ErrMalformed},
+		{"zero key", TypeKEX, func([]byte) []byte { return make([]byte, 32) }, ErrMalformed},
+		{"other frame", 0x01, func([]byte) []byte { return nil }, ErrUnexpectedFrame},
+		{"reflected", TypeKEX, func(own []byte) []byte { return own }, ErrReflected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ca, cb := net.Pipe()
+			defer ca.Close()
+			defer cb.Close()
+
+			go func() {
+				peer := enproto.NewFramer(cb)
+				_, own, err := peer.ReadFrame()
+				if err != nil {
+					return
+				}
+				peer.WriteFrame(tt.msgType, tt.payload(own))
+			}()
+			if _, err := Exchange(enproto.NewFramer(ca), nil); !errors.Is(err, tt.want) {
+				t.Errorf("got %v; want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestTranscript verifies negotiated frames bound through a Transcript must match on
+// both ends, and that the IVs pair up like the keys.
+func TestTranscript(t *testing.T) {
+	negotiated := func(frames ...string) []byte {
+		tr := NewTranscript()
+		for _, f := range frames {
+			tr.Add(0x01, []byte(f))
+		}
+		return tr.Sum()
+	}
+
+	a, b := exchangePair(t, negotiated("version 2", "compress"), negotiated("version 2", "compress"))
+	if a.Send != b.Receive || a.SendIV != b.ReceiveIV || a.ReceiveIV != b.SendIV {
+		t.Error("keys or IVs do not match across ends")
+	}
+
+	// The same bytes split differently are a different negotiation.
+	c, d := exchangePair(t, negotiated("version 2", "compress"), negotiated("version 2compress"))
+	if c.Transcript == d.Transcript {
+		t.Error("transcripts agree despite different frames")
+	}
+}
+
+// TestExportKeyingMaterial verifies both ends export the same material, distinct per
+// label and context.
+func TestExportKeyingMaterial(t *testing.T) {
+	a, b := exchangePair(t, nil, nil)
+
+	ea, err := a.ExportKeyingMaterial("token binding", []byte("ctx"), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eb, _ := b.ExportKeyingMaterial("token binding", []byte("ctx"), 100)
+	if len(ea) != 100 || string(ea) != string(eb) {
+		t.Error("ends exported different material")
+	}
+
+	other, _ := a.ExportKeyingMaterial("token binding", []byte("other"), 100)
+	relabelled, _ := a.ExportKeyingMaterial("token bindin", []byte("gctx"), 100)
+	if string(other) == string(ea) || string(relabelled) == string(ea) {
+		t.Error("material does not depend on label and context")
+	}
+	short, _ := a.ExportKeyingMaterial("token binding", []byte("ctx"), 16)
+	if string(short) != string(ea[:16]) {
+		t.Error("shorter export is not a prefix")
+	}
+
+	if _, err := a.ExportKeyingMaterial("x", nil, 255*32+1); !errors.Is(err, ErrExportTooLong) {
+		t.Errorf("got %v; want ErrExportTooLong", err)
+	}
+}
+
+// TestKeys_Wipe verifies Wipe clears the keys and the exporter secret.
+func TestKeys_Wipe(t *testing.T) {
+	a, _ := exchangePair(t, nil, nil)
+	exporter := a.exporter
+	a.Wipe()
+	var zero [KeySize]byte
+	if a.Send != zero || a.Receive != zero || a.exporter != nil {
+		t.Error("keys survived Wipe")
+	}
+	if !bytes.Equal(exporter, make([]byte, len(exporter))) {
+		t.Error("exporter secret survived Wipe")
+	}
+}
+
+// slowWriter finishes each write after a delay and records that it has.
+type slowWriter struct{ done atomic.Bool }
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(20 * time.Millisecond)
+	w.done.Store(true)
+	return len(p), nil
+}
+
+// TestExchange_WaitsForWrite verifies a failed read does not return while the KEX
+// frame is still being written.
+func TestExchange_WaitsForWrite(t *testing.T) {
+	w := &slowWriter{}
+	_, err := Exchange(enproto.NewFramerRW(&bytes.Buffer{}, w), nil)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("Exchange = %v; want io.EOF", err)
+	}
+	if !w.done.Load() {
+		t.Error("Exchange returned with its write still in progress")
+	}
+}
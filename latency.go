@@ -0,0 +1,78 @@
+package enproto
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets is the number of buckets in a LatencyHistogram.
+const LatencyBuckets = 24
+
+// LatencyBucketBound returns the exclusive upper bound of bucket i of a
+// LatencyHistogram: 2^i microseconds, from 1µs up to about 4.2s. The last bucket has
+// no bound and counts everything slower.
+func LatencyBucketBound(i int) time.Duration {
+	if i >= LatencyBuckets-1 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Microsecond << i
+}
+
+// LatencyHistogram is a distribution of handler latencies over exponential buckets.
+type LatencyHistogram struct {
+	Count   uint64
+	Sum     time.Duration
+	Buckets [LatencyBuckets]uint64 // see LatencyBucketBound
+}
+
+// Mean returns the average latency, or 0 with no samples.
+func (h *LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns an upper bound on the q-quantile latency, 0 <= q <= 1: the bound
+// of the bucket it falls in. It returns 0 with no samples.
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.Count))
+	var seen uint64
+	for i, n := range h.Buckets {
+		seen += n
+		if seen > rank || seen == h.Count {
+			return LatencyBucketBound(i)
+		}
+	}
+	return LatencyBucketBound(LatencyBuckets - 1)
+}
+
+// latencyRecorder accumulates a LatencyHistogram from concurrent goroutines.
+type latencyRecorder struct {
+	count   atomic.Uint64
+	sum     atomic.Int64
+	buckets [LatencyBuckets]atomic.Uint64
+}
+
+func (r *latencyRecorder) record(d time.Duration) {
+	i := 0
+	for i < LatencyBuckets-1 && d >= LatencyBucketBound(i) {
+		i++
+	}
+	r.buckets[i].Add(1)
+	r.sum.Add(int64(d))
+	r.count.Add(1)
+}
+
+func (r *latencyRecorder) snapshot() LatencyHistogram {
+	// count is incremented last, so reading it first keeps it within the buckets.
+	h := LatencyHistogram{Count: r.count.Load()}
+	h.Sum = time.Duration(r.sum.Load())
+	for i := range r.buckets {
+		h.Buckets[i] = r.buckets[i].Load()
+	}
+	return h
+}
@@ -0,0 +1,40 @@
+package enproto
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLatencyHistogram verifies bucketing, mean and quantiles.
+func TestLatencyHistogram(t *testing.T) {
+	var r latencyRecorder
+	for i := 0; i < 90; i++ {
+		r.record(3 * time.Microsecond) // bucket 2: [2µs, 4µs)
+	}
+	for i := 0; i < 10; i++ {
+		r.record(time.Millisecond) // bucket 10: [512µs, 1024µs)
+	}
+	r.record(time.Minute) // overflow
+
+	h := r.snapshot()
+	if h.Count != 101 || h.Buckets[2] != 90 || h.Buckets[10] != 10 || h.Buckets[LatencyBuckets-1] != 1 {
+		t.Errorf("histogram = %+v", h)
+	}
+	if got, want := h.Mean(), (90*3*time.Microsecond+10*time.Millisecond+time.Minute)/101; got != want {
+		t.Errorf("Mean = %v; want %v", got, want)
+	}
+	if got := h.Quantile(0.5); got != 4*time.Microsecond {
+		t.Errorf("p50 = %v; want 4µs", got)
+	}
+	if got := h.Quantile(0.95); got != 1024*time.Microsecond {
+		t.Errorf("p95 = %v; want 1.024ms", got)
+	}
+	if got := h.Quantile(1); got != LatencyBucketBound(LatencyBuckets-1) {
+		t.Errorf("p100 = %v; want the overflow bound", got)
+	}
+
+	var empty LatencyHistogram
+	if empty.Mean() != 0 || empty.Quantile(0.5) != 0 {
+		t.Error("empty histogram reports latency")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !enprotodebug
+
+package enproto
+
+// leakInfo is empty outside enprotodebug builds, which do no leak tracking.
+type leakInfo struct{}
+
+func trackLeak(*Payload)   {}
+func untrackLeak(*Payload) {}
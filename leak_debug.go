@@ -0,0 +1,38 @@
+//go:build enprotodebug
+
+package enproto
+
+import (
+	"log"
+	"runtime"
+	"strings"
+)
+
+// leakInfo records where a Payload was read, for the report if it leaks.
+type leakInfo struct {
+	stack []uintptr
+}
+
+// trackLeak arranges for an unreleased Payload to be reported when collected.
+func trackLeak(p *Payload) {
+	pcs := make([]uintptr, 32)
+	p.leak.stack = pcs[:runtime.Callers(3, pcs)]
+	runtime.SetFinalizer(p, reportLeak)
+}
+
+func untrackLeak(p *Payload) {
+	runtime.SetFinalizer(p, nil)
+}
+
+func reportLeak(p *Payload) {
+	var b strings.Builder
+	frames := runtime.CallersFrames(p.leak.stack)
+	for {
+		f, more := frames.Next()
+		b.WriteString("\n\t" + f.Function + " " + f.File)
+		if !more {
+			break
+		}
+	}
+	log.Printf("enproto: Payload of %d bytes was never released; read at:%s", len(p.buf), b.String())
+}
@@ -0,0 +1,115 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// ErrDisconnect is wrapped by FrameMonitor errors for frames its Inspector rejected
+// with VerdictDisconnect. Serve loops return it, so the caller can close the
+// connection.
+var ErrDisconnect = errors.New("connection rejected by inspector")
+
+// SizeBuckets is the number of buckets in FrameStats.Sizes.
+const SizeBuckets = 33
+
+// FrameStats summarises the frames a connection has delivered.
+type FrameStats struct {
+	Frames uint64
+	Bytes  uint64 // payload bytes
+	Types  [256]uint64
+	// Sizes is a histogram of payload sizes: bucket 0 counts empty payloads and
+	// bucket i payloads of 2^(i-1) to 2^i-1 bytes.
+	Sizes   [SizeBuckets]uint64
+	Started time.Time // when the first frame arrived
+	Last    time.Time // when the latest frame arrived
+}
+
+// Rate returns the average frames per second between the first and latest frame.
+func (s *FrameStats) Rate() float64 {
+	d := s.Last.Sub(s.Started).Seconds()
+	if d <= 0 {
+		return 0
+	}
+	return float64(s.Frames) / d
+}
+
+// Verdict is an Inspector's decision on a frame.
+type Verdict int
+
+const (
+	// VerdictAllow passes the frame on.
+	VerdictAllow Verdict = iota
+	// VerdictDrop drops the frame and keeps serving.
+	VerdictDrop
+	// VerdictDisconnect drops the frame and ends serving with ErrDisconnect.
+	VerdictDisconnect
+)
+
+// Inspector judges frames given statistics of the connection so far, including the
+// frame itself, for detecting scanning or abuse. stats must not be retained or
+// modified.
+type Inspector interface {
+	Inspect(msgType byte, payload []byte, stats *FrameStats) Verdict
+}
+
+// InspectorFunc adapts an ordinary function to the Inspector interface.
+type InspectorFunc func(msgType byte, payload []byte, stats *FrameStats) Verdict
+
+// Inspect calls fn(msgType, payload, stats).
+func (fn InspectorFunc) Inspect(msgType byte, payload []byte, stats *FrameStats) Verdict {
+	return fn(msgType, payload, stats)
+}
+
+// FrameMonitor is a Handler that keeps FrameStats for one connection and asks an
+// Inspector about every frame before passing it on. It is safe for concurrent use.
+type FrameMonitor struct {
+	h    Handler
+	insp Inspector
+	now  func() time.Time // for tests
+
+	mu    sync.Mutex
+	stats FrameStats
+}
+
+// NewFrameMonitor returns a FrameMonitor in front of h consulting insp. Use one per
+// connection.
+func NewFrameMonitor(h Handler, insp Inspector) *FrameMonitor {
+	return &FrameMonitor{h: h, insp: insp, now: time.Now}
+}
+
+// ServeFrame records the frame and applies the Inspector's verdict.
+func (m *FrameMonitor) ServeFrame(msgType byte, payload []byte) error {
+	now := m.now()
+
+	m.mu.Lock()
+	s := &m.stats
+	if s.Frames == 0 {
+		s.Started = now
+	}
+	s.Last = now
+	s.Frames++
+	s.Bytes += uint64(len(payload))
+	s.Types[msgType]++
+	s.Sizes[bits.Len32(uint32(len(payload)))]++
+	v := m.insp.Inspect(msgType, payload, s)
+	m.mu.Unlock()
+
+	switch v {
+	case VerdictDrop:
+		return nil
+	case VerdictDisconnect:
+		return fmt.Errorf("%w: type %d", ErrDisconnect, msgType)
+	}
+	return m.h.ServeFrame(msgType, payload)
+}
+
+// Stats returns a copy of the connection's statistics.
+func (m *FrameMonitor) Stats() FrameStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
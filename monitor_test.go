@@ -0,0 +1,67 @@
+package enproto
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFrameMonitor verifies statistics are kept and verdicts applied.
+func TestFrameMonitor(t *testing.T) {
+	var delivered int
+	h := HandlerFunc(func(byte, []byte) error {
+		delivered++
+		return nil
+	})
+
+	// Drop oversized frames; disconnect peers probing more than three types.
+	insp := InspectorFunc(func(msgType byte, payload []byte, s *FrameStats) Verdict {
+		types := 0
+		for _, n := range s.Types {
+			if n > 0 {
+				types++
+			}
+		}
+		switch {
+		case types > 3:
+			return VerdictDisconnect
+		case len(payload) > 100:
+			return VerdictDrop
+		}
+		return VerdictAllow
+	})
+
+	clock := time.Unix(0, 0)
+	m := NewFrameMonitor(h, insp)
+	m.now = func() time.Time { return clock }
+
+	for i, f := range []Frame{
+		{Type: 1, Payload: nil},
+		{Type: 1, Payload: make([]byte, 3)},
+		{Type: 2, Payload: make([]byte, 200)},
+		{Type: 3, Payload: make([]byte, 4)},
+	} {
+		if err := m.ServeFrame(f.Type, f.Payload); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		clock = clock.Add(time.Second)
+	}
+	if err := m.ServeFrame(4, nil); !errors.Is(err, ErrDisconnect) {
+		t.Errorf("fourth type: got %v; want ErrDisconnect", err)
+	}
+
+	if delivered != 3 {
+		t.Errorf("delivered %d frames; want 3", delivered)
+	}
+	s := m.Stats()
+	if s.Frames != 5 || s.Bytes != 207 || s.Types[1] != 2 {
+		t.Errorf("stats = %d frames, %d bytes, %d of type 1", s.Frames, s.Bytes, s.Types[1])
+	}
+	// 0 -> bucket 0 (twice), 3 -> bucket 2, 4 -> bucket 3, 200 -> bucket 8.
+	if s.Sizes[0] != 2 || s.Sizes[2] != 1 || s.Sizes[3] != 1 || s.Sizes[8] != 1 {
+		t.Errorf("size histogram = %v", s.Sizes[:9])
+	}
+	if r := s.Rate(); r != 5.0/4 {
+		t.Errorf("Rate = %v; want 1.25", r)
+	}
+}
@@ -0,0 +1,99 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNeedMore is returned by NonblockingFramer.Next when no complete frame has been
+// fed yet.
+var ErrNeedMore = errors.New("need more data")
+
+// NonblockingFramer frames a connection driven by readiness notifications, such as a
+// syscall.RawConn or a netpoll library, instead of blocking reads and writes. It never
+// performs I/O itself: the event loop feeds it whatever bytes were readable, takes
+// complete frames from Next, queues outgoing frames, and drains them with Flush
+// whenever the connection is writable.
+//
+// It accepts the same options as a Framer for validation, filtering and the written
+// header. It is not safe for concurrent use.
+type NonblockingFramer struct {
+	parser *Parser
+	ready  []Frame // frames fed but not yet returned by Next
+
+	cfg config
+	out []byte // encoded frames, of which out[off:] are not yet written
+	off int
+}
+
+// NewNonblockingFramer returns a NonblockingFramer configured by opts.
+func NewNonblockingFramer(opts ...Option) *NonblockingFramer {
+	cfg := newConfig(opts)
+	return &NonblockingFramer{
+		parser: &Parser{cfg: cfg},
+		cfg:    cfg,
+	}
+}
+
+// Feed parses bytes read from the connection. Frames it completes are returned by
+// later calls to Next. Errors are those of Parser.Feed; frames completed before an
+// error are still kept.
+func (f *NonblockingFramer) Feed(b []byte) error {
+	frames, err := f.parser.Feed(b)
+	f.ready = append(f.ready, frames...)
+	return err
+}
+
+// Next returns the oldest complete frame fed so far, or ErrNeedMore if there is none
+// and the event loop should wait until the connection is readable again.
+func (f *NonblockingFramer) Next() (Frame, error) {
+	if len(f.ready) == 0 {
+		return Frame{}, ErrNeedMore
+	}
+	frame := f.ready[0]
+	f.ready[0] = Frame{}
+	f.ready = f.ready[1:]
+	return frame, nil
+}
+
+// QueueFrame encodes a frame onto the outgoing queue. Nothing is written until Flush.
+func (f *NonblockingFramer) QueueFrame(msgType byte, payload []byte) error {
+	if uint64(len(payload)) > uint64(f.cfg.maxFrameSize) {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, len(payload))
+	}
+	f.out = AppendHeader(f.out, Header{
+		Magic:   f.cfg.magic,
+		Version: f.cfg.version,
+		Type:    msgType,
+		Length:  uint32(len(payload)),
+	})
+	f.out = append(f.out, payload...)
+	return nil
+}
+
+// Pending returns the number of queued bytes not yet written.
+func (f *NonblockingFramer) Pending() int {
+	return len(f.out) - f.off
+}
+
+// Flush offers every queued byte to write in one call and dequeues as many as it
+// accepted. A short write with a nil error means the connection would block: the
+// rest stays queued for the next Flush, once the connection is writable again. Inside
+// a syscall.RawConn Write callback, map EAGAIN to a short write and return false
+// while Pending is non-zero.
+func (f *NonblockingFramer) Flush(write func(p []byte) (int, error)) error {
+	if f.Pending() == 0 {
+		return nil
+	}
+	n, err := write(f.out[f.off:])
+	f.off += n
+	switch {
+	case f.off == len(f.out):
+		f.out, f.off = f.out[:0], 0
+	case f.off > len(f.out)/2:
+		// Mostly written: move the rest down so the queue does not creep forward.
+		rest := copy(f.out, f.out[f.off:])
+		f.out, f.off = f.out[:rest], 0
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestNonblockingFramer_Read verifies frames become available as bytes are fed.
+func TestNonblockingFramer_Read(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	fw.WriteFrame(0x01, []byte("one"))
+	fw.WriteFrame(0x02, []byte("two"))
+	wire := buf.Bytes()
+
+	f := NewNonblockingFramer()
+	if _, err := f.Next(); !errors.Is(err, ErrNeedMore) {
+		t.Fatalf("Next on empty framer = %v; want %v", err, ErrNeedMore)
+	}
+
+	if err := f.Feed(wire[:5]); err != nil {
+		t.Fatalf("Feed: %v", err)
+	}
+	if _, err := f.Next(); !errors.Is(err, ErrNeedMore) {
+		t.Fatalf("Next mid-header = %v; want %v", err, ErrNeedMore)
+	}
+
+	if err := f.Feed(wire[5:]); err != nil {
+		t.Fatalf("Feed: %v", err)
+	}
+	for _, want := range []string{"one", "two"} {
+		frame, err := f.Next()
+		if err != nil || string(frame.Payload) != want {
+			t.Errorf("Next = %q, %v; want %q", frame.Payload, err, want)
+		}
+	}
+	if _, err := f.Next(); !errors.Is(err, ErrNeedMore) {
+		t.Errorf("Next after draining = %v; want %v", err, ErrNeedMore)
+	}
+}
+
+// TestNonblockingFramer_Flush verifies queued bytes survive short writes.
+func TestNonblockingFramer_Flush(t *testing.T) {
+	f := NewNonblockingFramer()
+	if err := f.QueueFrame(0x01, []byte("hello")); err != nil {
+		t.Fatalf("QueueFrame: %v", err)
+	}
+	if err := f.QueueFrame(0x02, []byte("world")); err != nil {
+		t.Fatalf("QueueFrame: %v", err)
+	}
+
+	// A connection that accepts at most 4 bytes per readiness event.
+	var wire bytes.Buffer
+	write := func(p []byte) (int, error) {
+		return wire.Write(p[:min(len(p), 4)])
+	}
+	for i := 0; f.Pending() > 0; i++ {
+		if i > 100 {
+			t.Fatalf("Flush made no progress; %d bytes pending", f.Pending())
+		}
+		if err := f.Flush(write); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	}
+
+	fr := NewFrameReader(&wire)
+	for _, want := range []string{"hello", "world"} {
+		if _, p, err := fr.ReadFrame(); err != nil || string(p) != want {
+			t.Errorf("ReadFrame = %q, %v; want %q", p, err, want)
+		}
+	}
+}
+
+// TestNonblockingFramer_FlushError verifies write errors are returned with the
+// unwritten bytes still queued.
+func TestNonblockingFramer_FlushError(t *testing.T) {
+	f := NewNonblockingFramer()
+	f.QueueFrame(0x01, []byte("data"))
+
+	errClosed := errors.New("closed")
+	err := f.Flush(func(p []byte) (int, error) { return 2, errClosed })
+	if !errors.Is(err, errClosed) {
+		t.Errorf("Flush error = %v; want %v", err, errClosed)
+	}
+	if f.Pending() != HeaderSize+4-2 {
+		t.Errorf("Pending = %d; want %d", f.Pending(), HeaderSize+4-2)
+	}
+}
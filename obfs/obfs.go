@@ -0,0 +1,105 @@
+// Package obfs hides enproto traffic from protocol fingerprinting by masking every
+// byte of a stream, header magic included, with a keystream derived from a
+// pre-shared key.
+//
+// Each direction of the stream starts with a random 16-byte nonce sent in the clear;
+// the rest of the direction is XORed with AES-256-CTR keyed by HMAC-SHA256(psk,
+// label || nonce), so every session looks different on the wire. Both ends wrap their
+// transport with the same key.
+//
+// This is obfuscation, not encryption: there is no integrity protection and frame
+// sizes and timing stay visible. Use TLS, or keys from package kex, for secrecy.
+package obfs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"sync"
+)
+
+// NonceSize is the length of the nonce that starts each direction.
+const NonceSize = 16
+
+const label = "enproto obfs v1"
+
+// Conn masks the bytes written to and unmasks the bytes read from a transport.
+// Reads and writes may run concurrently with each other.
+type Conn struct {
+	rw  io.ReadWriter
+	psk []byte
+
+	rmu sync.Mutex
+	r   cipher.Stream
+
+	wmu  sync.Mutex
+	w    cipher.Stream
+	wbuf []byte
+}
+
+// Wrap returns a Conn over rw masking with psk. Pass it to enproto.NewFramer in
+// place of rw.
+func Wrap(rw io.ReadWriter, psk []byte) *Conn {
+	return &Conn{rw: rw, psk: psk}
+}
+
+// Read reads and unmasks bytes, first reading the peer's nonce if it has not yet.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	if c.r == nil {
+		var nonce [NonceSize]byte
+		if _, err := io.ReadFull(c.rw, nonce[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF // a stream cut off in its nonce carried no data
+			}
+			return 0, err
+		}
+		c.r = c.stream(nonce[:])
+	}
+
+	n, err := c.rw.Read(p)
+	c.r.XORKeyStream(p[:n], p[:n])
+	return n, err
+}
+
+// Write masks and writes p, preceded on the first call by this end's nonce. p is
+// not modified.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.wbuf = c.wbuf[:0]
+	if c.w == nil {
+		var nonce [NonceSize]byte
+		if _, err := rand.Read(nonce[:]); err != nil {
+			return 0, err
+		}
+		c.w = c.stream(nonce[:])
+		c.wbuf = append(c.wbuf, nonce[:]...)
+	}
+	prefix := len(c.wbuf)
+	c.wbuf = append(c.wbuf, p...)
+	c.w.XORKeyStream(c.wbuf[prefix:], p)
+
+	n, err := c.rw.Write(c.wbuf)
+	if n < prefix {
+		// The nonce did not get out whole, so nothing written since can be read.
+		return 0, err
+	}
+	return n - prefix, err
+}
+
+// stream returns the keystream for a direction starting with nonce.
+func (c *Conn) stream(nonce []byte) cipher.Stream {
+	m := hmac.New(sha256.New, c.psk)
+	m.Write([]byte(label))
+	m.Write(nonce)
+	block, _ := aes.NewCipher(m.Sum(nil)) // a 32-byte key never fails
+	// The key is unique to the nonce, so a zero IV is safe.
+	return cipher.NewCTR(block, make([]byte, aes.BlockSize))
+}
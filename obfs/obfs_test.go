@@ -0,0 +1,78 @@
+package obfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// pipe is one direction of a connection, recording what crossed the wire.
+type pipe struct {
+	wire bytes.Buffer
+}
+
+func (p *pipe) Read(b []byte) (int, error)  { return p.wire.Read(b) }
+func (p *pipe) Write(b []byte) (int, error) { return p.wire.Write(b) }
+
+// TestRoundTrip verifies frames survive masking and the wire shows no magic.
+func TestRoundTrip(t *testing.T) {
+	psk := []byte("shared secret")
+	var p pipe
+
+	w := enproto.NewFrameWriter(Wrap(&p, psk))
+	for i := 0; i < 3; i++ {
+		if err := w.WriteFrame(0x01, []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wire := bytes.Clone(p.wire.Bytes())
+
+	var plain bytes.Buffer
+	enproto.NewFrameWriter(&plain).WriteFrame(0x01, []byte("hello"))
+	frame := plain.Len()
+	if bytes.Equal(wire[NonceSize:NonceSize+2], plain.Bytes()[:2]) || bytes.Contains(wire, []byte("hello")) {
+		t.Error("masked stream shows magic or payload")
+	}
+
+	r := enproto.NewFrameReader(Wrap(&p, psk))
+	for i := 0; i < 3; i++ {
+		msgType, payload, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if msgType != 0x01 || string(payload) != "hello" {
+			t.Errorf("frame %d = %d %q", i, msgType, payload)
+		}
+	}
+	if _, _, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("after last frame: %v; want EOF", err)
+	}
+
+	// A second session with the same key looks different.
+	var q pipe
+	enproto.NewFrameWriter(Wrap(&q, psk)).WriteFrame(0x01, []byte("hello"))
+	if bytes.Equal(q.wire.Bytes()[NonceSize:], wire[NonceSize:NonceSize+frame]) {
+		t.Error("two sessions produced the same masked bytes")
+	}
+}
+
+// TestWrongKey verifies a peer with another key cannot decode the stream.
+func TestWrongKey(t *testing.T) {
+	var p pipe
+	enproto.NewFrameWriter(Wrap(&p, []byte("key a"))).WriteFrame(0x01, []byte("hello"))
+
+	_, _, err := enproto.NewFrameReader(Wrap(&p, []byte("key b"))).ReadFrame()
+	if err == nil {
+		t.Fatal("read a frame with the wrong key")
+	}
+}
+
+// TestEmptyStream verifies a stream closed before its nonce reads as EOF.
+func TestEmptyStream(t *testing.T) {
+	if _, err := Wrap(&pipe{}, nil).Read(make([]byte, 8)); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v; want EOF", err)
+	}
+}
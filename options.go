@@ -0,0 +1,101 @@
+package enproto
+
+import "time"
+
+// defaultBufferSize is the size of the bufio reader and writer wrapped around the transport.
+const defaultBufferSize = 64 * 1024
+
+// config holds the settings a Framer is constructed with.
+type config struct {
+	magic            uint16
+	version          byte
+	acceptedVersions []byte
+	maxFrameSize     uint32
+	readBufferSize   int
+	writeBufferSize  int
+	ownership        Ownership
+	filter           Filter
+	allocator        Allocator
+	spillThreshold   uint32
+	spillDir         string
+	frameReadTimeout time.Duration
+	zeroOnRelease    bool
+	counters         *frameCounters
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		magic:           Magic,
+		version:         ProtocolVersion,
+		maxFrameSize:    maxAllowed,
+		readBufferSize:  defaultBufferSize,
+		writeBufferSize: defaultBufferSize,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// acceptsVersion reports whether frames carrying version v may be read.
+func (c *config) acceptsVersion(v byte) bool {
+	if v == c.version {
+		return true
+	}
+	for _, av := range c.acceptedVersions {
+		if v == av {
+			return true
+		}
+	}
+	return false
+}
+
+// Option configures a Framer.
+type Option func(*config)
+
+// WithMaxFrameSize sets the largest payload, in bytes, that will be read or written.
+// The default is 100 MiB.
+func WithMaxFrameSize(n uint32) Option {
+	return func(c *config) {
+		c.maxFrameSize = n
+	}
+}
+
+// WithMagic sets the magic number written to and expected in every frame header.
+// The default is Magic.
+func WithMagic(magic uint16) Option {
+	return func(c *config) {
+		c.magic = magic
+	}
+}
+
+// WithVersion sets the protocol version written to outgoing frames. Incoming frames
+// must carry the same version unless others are allowed with WithAcceptedVersions.
+// The default is ProtocolVersion.
+func WithVersion(v byte) Option {
+	return func(c *config) {
+		c.version = v
+	}
+}
+
+// WithAcceptedVersions allows incoming frames to carry any of the given versions in
+// addition to the one being written.
+func WithAcceptedVersions(versions ...byte) Option {
+	return func(c *config) {
+		c.acceptedVersions = append(c.acceptedVersions, versions...)
+	}
+}
+
+// WithReadBufferSize sets the size of the buffered reader. The default is 64 KiB.
+func WithReadBufferSize(n int) Option {
+	return func(c *config) {
+		c.readBufferSize = n
+	}
+}
+
+// WithWriteBufferSize sets the size of the buffered writer. The default is 64 KiB.
+func WithWriteBufferSize(n int) Option {
+	return func(c *config) {
+		c.writeBufferSize = n
+	}
+}
@@ -0,0 +1,82 @@
+package enproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestWithMaxFrameSize ensures the configured limit is enforced on both read and write.
+func TestWithMaxFrameSize(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf, WithMaxFrameSize(4))
+
+	if err := fr.WriteFrame(0x1, []byte("too long")); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge on write, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written for rejected frame, got %d bytes", buf.Len())
+	}
+
+	// A frame written by a Framer with the default limit is rejected on read.
+	if err := NewFramer(buf).WriteFrame(0x1, []byte("too long")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if _, _, err := fr.ReadFrame(); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge on read, got %v", err)
+	}
+}
+
+// TestWithMagic verifies a custom magic is written and required on read.
+func TestWithMagic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf, WithMagic(0x1234))
+
+	if err := fr.WriteFrame(0x1, []byte("payload")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if magic := binary.BigEndian.Uint16(buf.Bytes()[0:2]); magic != 0x1234 {
+		t.Errorf("magic = %#x; want %#x", magic, 0x1234)
+	}
+
+	if _, _, err := NewFramer(bytes.NewBuffer(buf.Bytes())).ReadFrame(); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("expected ErrBadMagic with default magic, got %v", err)
+	}
+	if _, _, err := fr.ReadFrame(); err != nil {
+		t.Errorf("ReadFrame error: %v", err)
+	}
+}
+
+// TestWithVersion verifies the written version and the accepted version policy.
+func TestWithVersion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewFramer(buf, WithVersion(ProtocolVersion+1)).WriteFrame(0x1, nil); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if v := buf.Bytes()[2]; v != ProtocolVersion+1 {
+		t.Errorf("version = %d; want %d", v, ProtocolVersion+1)
+	}
+	wire := buf.Bytes()
+
+	if _, _, err := NewFramer(bytes.NewBuffer(wire)).ReadFrame(); !errors.Is(err, ErrBadVersion) {
+		t.Errorf("expected ErrBadVersion, got %v", err)
+	}
+
+	fr := NewFramer(bytes.NewBuffer(wire), WithAcceptedVersions(ProtocolVersion+1))
+	if _, _, err := fr.ReadFrame(); err != nil {
+		t.Errorf("ReadFrame error with accepted version: %v", err)
+	}
+}
+
+// TestWithBufferSizes verifies the configured buffer sizes are used.
+func TestWithBufferSizes(t *testing.T) {
+	fr := NewFramer(&bytes.Buffer{}, WithReadBufferSize(32), WithWriteBufferSize(16))
+
+	if size := fr.br.Size(); size != 32 {
+		t.Errorf("read buffer size = %d; want 32", size)
+	}
+	if size := fr.bw.Size(); size != 16 {
+		t.Errorf("write buffer size = %d; want 16", size)
+	}
+}
@@ -0,0 +1,135 @@
+package enproto
+
+import (
+	"errors"
+	"sync"
+)
+
+// Ownership selects who owns the payload slices returned by ReadFrame.
+type Ownership int
+
+const (
+	// OwnershipCopy makes ReadFrame return a freshly allocated payload that the caller
+	// owns outright and may retain or mutate indefinitely. This is the default.
+	OwnershipCopy Ownership = iota
+
+	// OwnershipBorrow makes ReadFrame return a payload lent from a buffer pool. The
+	// caller must hand it back with Release once done and must not touch it afterwards.
+	// Unreleased payloads are simply left to the garbage collector.
+	OwnershipBorrow
+)
+
+// ErrNotBorrowed is returned by Release for a payload that is not currently on loan
+// from the FrameReader, such as one released twice.
+var ErrNotBorrowed = errors.New("payload not borrowed from this reader")
+
+// WithPayloadOwnership selects whether ReadFrame copies payloads or lends pooled
+// buffers that must be returned with Release. The default is OwnershipCopy.
+func WithPayloadOwnership(o Ownership) Option {
+	return func(c *config) {
+		c.ownership = o
+	}
+}
+
+// WithZeroOnRelease makes Release wipe every lent payload before its buffer goes back
+// to the Allocator, so decrypted data or key material cannot leak into the next
+// frame read into the buffer or linger in pooled memory. To wipe only some payloads,
+// mark them with MarkSensitive instead.
+func WithZeroOnRelease() Option {
+	return func(c *config) {
+		c.zeroOnRelease = true
+	}
+}
+
+// loans tracks the buffers currently lent to callers.
+type loans struct {
+	alloc Allocator
+	zero  bool // wipe every buffer on release
+	mu    sync.Mutex
+	out   map[*byte]loan // first element -> loan
+}
+
+type loan struct {
+	buf       []byte // as allocated
+	sensitive bool
+}
+
+// borrow returns an allocated payload of length n and records it as lent. At least
+// one byte is requested so that every lent payload has a distinct first element.
+func (l *loans) borrow(n int) []byte {
+	buf := l.alloc.Alloc(max(n, 1))
+
+	l.mu.Lock()
+	if l.out == nil {
+		l.out = make(map[*byte]loan)
+	}
+	l.out[&buf[0]] = loan{buf: buf}
+	l.mu.Unlock()
+
+	return buf[:n]
+}
+
+// release hands a lent payload back to the allocator, wiping it first if needed.
+func (l *loans) release(payload []byte) error {
+	if cap(payload) == 0 {
+		return ErrNotBorrowed
+	}
+	key := &payload[:1][0]
+
+	l.mu.Lock()
+	ln, ok := l.out[key]
+	delete(l.out, key)
+	l.mu.Unlock()
+
+	if !ok {
+		return ErrNotBorrowed
+	}
+	if l.zero || ln.sensitive {
+		clear(ln.buf)
+	}
+	l.alloc.Free(ln.buf)
+	return nil
+}
+
+// markSensitive flags a lent payload to be wiped on release.
+func (l *loans) markSensitive(payload []byte) error {
+	if cap(payload) == 0 {
+		return ErrNotBorrowed
+	}
+	key := &payload[:1][0]
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ln, ok := l.out[key]
+	if !ok {
+		return ErrNotBorrowed
+	}
+	ln.sensitive = true
+	l.out[key] = ln
+	return nil
+}
+
+// Release returns a payload obtained from ReadFrame in OwnershipBorrow mode so its
+// buffer can be reused. Pass the slice as returned by ReadFrame; it must not be used
+// after Release. Releasing a payload twice, or one that was never lent, returns
+// ErrNotBorrowed rather than letting two readers alias one buffer.
+//
+// In OwnershipCopy mode Release does nothing and returns nil, so code can call it
+// regardless of the configured mode. Release is safe for concurrent use.
+func (r *FrameReader) Release(payload []byte) error {
+	if r.cfg.ownership != OwnershipBorrow {
+		return nil
+	}
+	return r.loans.release(payload)
+}
+
+// MarkSensitive flags a payload lent in OwnershipBorrow mode to be wiped when it is
+// released, for handlers that find secrets in it. It returns ErrNotBorrowed for a
+// payload not on loan. In OwnershipCopy mode it does nothing and returns nil: the
+// caller owns the payload and clears it itself.
+func (r *FrameReader) MarkSensitive(payload []byte) error {
+	if r.cfg.ownership != OwnershipBorrow {
+		return nil
+	}
+	return r.loans.markSensitive(payload)
+}
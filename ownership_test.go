@@ -0,0 +1,122 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestOwnershipBorrow_Release verifies borrowed payloads can be released exactly once.
+func TestOwnershipBorrow_Release(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf, WithPayloadOwnership(OwnershipBorrow))
+
+	if err := fr.WriteFrame(0x1, []byte("borrowed")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	_, payload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if !bytes.Equal(payload, []byte("borrowed")) {
+		t.Errorf("payload = %q; want %q", payload, "borrowed")
+	}
+
+	if err := fr.Release(payload); err != nil {
+		t.Errorf("Release error: %v", err)
+	}
+	if err := fr.Release(payload); !errors.Is(err, ErrNotBorrowed) {
+		t.Errorf("expected ErrNotBorrowed on double release, got %v", err)
+	}
+	if err := fr.Release([]byte("never lent")); !errors.Is(err, ErrNotBorrowed) {
+		t.Errorf("expected ErrNotBorrowed for foreign slice, got %v", err)
+	}
+}
+
+// TestOwnershipBorrow_NoAliasing ensures outstanding payloads never share a buffer.
+func TestOwnershipBorrow_NoAliasing(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf, WithPayloadOwnership(OwnershipBorrow))
+
+	for _, p := range []string{"first", "second"} {
+		if err := fr.WriteFrame(0x1, []byte(p)); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	_, first, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	_, second, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+
+	if string(first) != "first" || string(second) != "second" {
+		t.Errorf("payloads = %q, %q; want %q, %q", first, second, "first", "second")
+	}
+}
+
+// TestOwnershipCopy_Release ensures Release is a harmless no-op in the default mode.
+func TestOwnershipCopy_Release(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf)
+
+	if err := fr.WriteFrame(0x1, []byte("copied")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	_, payload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+
+	if err := fr.Release(payload); err != nil {
+		t.Errorf("Release error in copy mode: %v", err)
+	}
+}
+
+// keepingAllocator hands out fresh buffers and keeps the ones freed for inspection.
+type keepingAllocator struct{ freed [][]byte }
+
+func (a *keepingAllocator) Alloc(n int) []byte { return make([]byte, n) }
+func (a *keepingAllocator) Free(buf []byte)    { a.freed = append(a.freed, buf) }
+
+// TestZeroOnRelease verifies released payloads are wiped with WithZeroOnRelease, and
+// otherwise only when marked sensitive.
+func TestZeroOnRelease(t *testing.T) {
+	readReleased := func(mark bool, opts ...Option) []byte {
+		var buf bytes.Buffer
+		NewFrameWriter(&buf).WriteFrame(0x1, []byte("secret"))
+		alloc := &keepingAllocator{}
+		fr := NewFrameReader(&buf, append(opts, WithPayloadOwnership(OwnershipBorrow), WithAllocator(alloc))...)
+		_, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mark {
+			if err := fr.MarkSensitive(payload); err != nil {
+				t.Fatalf("MarkSensitive: %v", err)
+			}
+		}
+		if err := fr.Release(payload); err != nil {
+			t.Fatal(err)
+		}
+		if err := fr.MarkSensitive(payload); !errors.Is(err, ErrNotBorrowed) {
+			t.Errorf("MarkSensitive after Release: got %v; want ErrNotBorrowed", err)
+		}
+		return alloc.freed[0]
+	}
+
+	zero := make([]byte, len("secret"))
+	if got := readReleased(false, WithZeroOnRelease()); !bytes.Equal(got, zero) {
+		t.Errorf("WithZeroOnRelease left %q", got)
+	}
+	if got := readReleased(true); !bytes.Equal(got, zero) {
+		t.Errorf("sensitive payload left %q", got)
+	}
+	if got := readReleased(false); string(got) != "secret" {
+		t.Errorf("unmarked payload was wiped: %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package enproto
+
+import "fmt"
+
+// Parser decodes frames from bytes pushed into it, for event loops and transports
+// that hand over data in chunks rather than exposing an io.Reader. Chunks may split
+// frames anywhere; the Parser keeps the incomplete tail until the rest arrives.
+//
+// A Parser applies the same options as a FrameReader for validation and filtering.
+// Options for the stream and its buffers have no effect. It is not safe for
+// concurrent use.
+type Parser struct {
+	cfg config
+
+	hdr    [HeaderSize]byte
+	hdrLen int // bytes of hdr filled so far
+
+	inFrame bool   // hdr is complete and payload is being collected
+	typ     byte   // type of the frame being collected
+	payload []byte // payload collected so far, with the full length as capacity
+	skip    int    // bytes still to drop for a filtered frame
+
+	err error // sticky protocol error
+}
+
+// NewParser returns a Parser configured by opts.
+func NewParser(opts ...Option) *Parser {
+	return &Parser{cfg: newConfig(opts)}
+}
+
+// Feed consumes b and returns the frames it completed, in order. Their payloads are
+// owned by the caller; b is not retained and may be reused once Feed returns.
+//
+// A header that fails validation stops the Parser: Feed returns the frames completed
+// before it along with the error, and every later call returns the same error. A frame
+// rejected by the Filter is discarded and reported with ErrFrameRejected once the rest
+// of b has been parsed, without stopping the Parser.
+func (p *Parser) Feed(b []byte) ([]Frame, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	var frames []Frame
+	var rejected error
+	for len(b) > 0 || p.inFrame {
+		if p.skip > 0 {
+			n := min(p.skip, len(b))
+			p.skip -= n
+			b = b[n:]
+			continue
+		}
+
+		if !p.inFrame {
+			n := copy(p.hdr[p.hdrLen:], b)
+			p.hdrLen += n
+			b = b[n:]
+			if p.hdrLen < HeaderSize {
+				break
+			}
+			p.hdrLen = 0
+
+			h := decodeHeader(p.hdr[:])
+			if err := p.cfg.validateHeader(h); err != nil {
+				p.err = err
+				return frames, err
+			}
+			if !p.admit(h, &rejected) {
+				continue
+			}
+			p.inFrame = true
+			p.typ = h.Type
+			p.payload = make([]byte, 0, h.Length)
+		}
+
+		n := min(cap(p.payload)-len(p.payload), len(b))
+		p.payload = append(p.payload, b[:n]...)
+		b = b[n:]
+		if len(p.payload) < cap(p.payload) {
+			break
+		}
+		frames = append(frames, Frame{Type: p.typ, Payload: p.payload})
+		p.inFrame = false
+		p.payload = nil
+	}
+	return frames, rejected
+}
+
+// admit applies the Filter to h, arranging for a skipped or rejected frame's payload
+// to be dropped. It records the first rejection in *rejected.
+func (p *Parser) admit(h Header, rejected *error) bool {
+	if p.cfg.filter == nil {
+		return true
+	}
+	action := p.cfg.filter(h)
+	if action == ActionDeliver {
+		return true
+	}
+	p.skip = int(h.Length)
+	if action == ActionError && *rejected == nil {
+		*rejected = fmt.Errorf("%w: type %d", ErrFrameRejected, h.Type)
+	}
+	return false
+}
+
+// Buffered returns how many bytes of an incomplete frame the Parser is holding.
+func (p *Parser) Buffered() int {
+	return p.hdrLen + len(p.payload)
+}
@@ -0,0 +1,97 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// parserWire encodes a few frames, including an empty one, for the Parser tests.
+func parserWire(t *testing.T) ([]byte, []Frame) {
+	t.Helper()
+	want := []Frame{
+		{Type: 0x01, Payload: []byte("hello")},
+		{Type: 0x02, Payload: []byte{}},
+		{Type: 0x03, Payload: bytes.Repeat([]byte("z"), 100)},
+	}
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	for _, f := range want {
+		if err := fw.WriteFrame(f.Type, f.Payload); err != nil {
+			t.Fatalf("WriteFrame: %v", err)
+		}
+	}
+	return buf.Bytes(), want
+}
+
+// TestParser_Feed verifies frames are reassembled whatever the chunk size.
+func TestParser_Feed(t *testing.T) {
+	wire, want := parserWire(t)
+
+	for _, chunk := range []int{1, 3, 8, 13, len(wire)} {
+		p := NewParser()
+		var got []Frame
+		for b := wire; len(b) > 0; {
+			n := min(chunk, len(b))
+			frames, err := p.Feed(b[:n])
+			if err != nil {
+				t.Fatalf("chunk %d: Feed error: %v", chunk, err)
+			}
+			got = append(got, frames...)
+			b = b[n:]
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("chunk %d: got %d frames; want %d", chunk, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Type != want[i].Type || !bytes.Equal(got[i].Payload, want[i].Payload) {
+				t.Errorf("chunk %d: frame %d = %v; want %v", chunk, i, got[i], want[i])
+			}
+		}
+		if p.Buffered() != 0 {
+			t.Errorf("chunk %d: Buffered = %d after whole frames; want 0", chunk, p.Buffered())
+		}
+	}
+}
+
+// TestParser_BadHeader ensures a corrupt header stops the Parser for good.
+func TestParser_BadHeader(t *testing.T) {
+	wire, _ := parserWire(t)
+	corrupt := append(append([]byte{}, wire[:13]...), 0xDE, 0xAD, 1, 1, 0, 0, 0, 0)
+
+	p := NewParser()
+	frames, err := p.Feed(corrupt)
+	if !errors.Is(err, ErrBadMagic) {
+		t.Fatalf("Feed error = %v; want %v", err, ErrBadMagic)
+	}
+	if len(frames) != 1 {
+		t.Errorf("got %d frames before the bad header; want 1", len(frames))
+	}
+	if _, err := p.Feed(wire); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("Feed after failure = %v; want sticky %v", err, ErrBadMagic)
+	}
+}
+
+// TestParser_Filter verifies skipped and rejected frames are dropped without
+// losing the frames around them.
+func TestParser_Filter(t *testing.T) {
+	wire, _ := parserWire(t)
+
+	p := NewParser(WithFilter(func(h Header) Action {
+		switch h.Type {
+		case 0x01:
+			return ActionSkip
+		case 0x02:
+			return ActionError
+		}
+		return ActionDeliver
+	}))
+	frames, err := p.Feed(wire)
+	if !errors.Is(err, ErrFrameRejected) {
+		t.Errorf("Feed error = %v; want %v", err, ErrFrameRejected)
+	}
+	if len(frames) != 1 || frames[0].Type != 0x03 {
+		t.Errorf("frames = %v; want only type 3", frames)
+	}
+}
@@ -0,0 +1,59 @@
+package enproto
+
+import "sync/atomic"
+
+// Payload is a frame payload lent by ReadPayload from the reader's Allocator. The
+// handler reads it through Bytes and calls Release when done, after which the buffer
+// is reused and must not be touched.
+//
+// Builds with the enprotodebug tag report Payloads that are garbage collected
+// without being released, along with the stack that read them.
+type Payload struct {
+	buf      []byte
+	r        *FrameReader
+	released atomic.Bool
+	leak     leakInfo
+}
+
+// Bytes returns the payload. The slice is only valid until Release.
+func (p *Payload) Bytes() []byte {
+	return p.buf
+}
+
+// Len returns the payload length in bytes.
+func (p *Payload) Len() int {
+	return len(p.buf)
+}
+
+// Release hands the buffer back for reuse. Releasing a Payload twice returns
+// ErrNotBorrowed. Release is safe for concurrent use.
+func (p *Payload) Release() error {
+	if p.released.Swap(true) {
+		return ErrNotBorrowed
+	}
+	untrackLeak(p)
+	buf := p.buf
+	p.buf = nil
+	return p.r.loans.release(buf)
+}
+
+// MarkSensitive flags the payload to be wiped when it is released.
+func (p *Payload) MarkSensitive() error {
+	if p.released.Load() {
+		return ErrNotBorrowed
+	}
+	return p.r.loans.markSensitive(p.buf)
+}
+
+// ReadPayload reads the next frame like ReadFrame in OwnershipBorrow mode, but wraps
+// the lent buffer in a Payload so that the buffer's owner is explicit in handler
+// signatures. It works whatever ownership mode the reader was configured with.
+func (r *FrameReader) ReadPayload() (msgType byte, p *Payload, err error) {
+	msgType, payload, err := r.readBorrowed()
+	if err != nil {
+		return 0, nil, err
+	}
+	p = &Payload{buf: payload, r: r}
+	trackLeak(p)
+	return msgType, p, nil
+}
@@ -0,0 +1,56 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestReadPayload verifies Payload handles carry the frame and release exactly once.
+func TestReadPayload(t *testing.T) {
+	var buf bytes.Buffer
+	NewFrameWriter(&buf).WriteFrame(0x07, []byte("handoff"))
+
+	alloc := &countingAllocator{}
+	fr := NewFrameReader(&buf, WithAllocator(alloc)) // copy mode: ReadPayload still lends
+	msgType, p, err := fr.ReadPayload()
+	if err != nil {
+		t.Fatalf("ReadPayload: %v", err)
+	}
+	if msgType != 0x07 || string(p.Bytes()) != "handoff" || p.Len() != 7 {
+		t.Errorf("payload = %d %q (len %d); want %d %q (len 7)", msgType, p.Bytes(), p.Len(), 0x07, "handoff")
+	}
+
+	if err := p.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := p.Release(); !errors.Is(err, ErrNotBorrowed) {
+		t.Errorf("second Release = %v; want %v", err, ErrNotBorrowed)
+	}
+	if alloc.allocs != 1 || alloc.frees != 1 {
+		t.Errorf("allocs, frees = %d, %d; want 1, 1", alloc.allocs, alloc.frees)
+	}
+}
+
+// TestPayload_MarkSensitive verifies a marked Payload is wiped on release.
+func TestPayload_MarkSensitive(t *testing.T) {
+	var buf bytes.Buffer
+	NewFrameWriter(&buf).WriteFrame(0x1, []byte("secret"))
+	alloc := &keepingAllocator{}
+	fr := NewFrameReader(&buf, WithAllocator(alloc))
+
+	_, p, err := fr.ReadPayload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MarkSensitive(); err != nil {
+		t.Fatal(err)
+	}
+	p.Release()
+	if got := alloc.freed[0]; !bytes.Equal(got, make([]byte, 6)) {
+		t.Errorf("released payload holds %q", got)
+	}
+	if err := p.MarkSensitive(); !errors.Is(err, ErrNotBorrowed) {
+		t.Errorf("MarkSensitive after Release: got %v; want ErrNotBorrowed", err)
+	}
+}
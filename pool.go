@@ -0,0 +1,19 @@
+package enproto
+
+import "github.com/ianchildress/enproto/internal/bufpool"
+
+// PoolStats reports how the shared payload buffer pool is doing. Payloads lent in
+// OwnershipBorrow mode, and buffers the subpackages use only for the duration of a
+// write, come from size classes of 1 KiB, 16 KiB, 256 KiB and larger.
+type PoolStats struct {
+	// Hits counts buffers served from the pool, Misses those that had to be allocated.
+	Hits   uint64
+	Misses uint64
+}
+
+// BufferPoolStats returns the pool's counters since the process started. They are
+// shared by every reader and writer in the process.
+func BufferPoolStats() PoolStats {
+	s := bufpool.ReadStats()
+	return PoolStats{Hits: s.Hits, Misses: s.Misses}
+}
@@ -0,0 +1,34 @@
+package enproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestBufferPoolStats verifies borrowed reads are counted by the shared pool.
+func TestBufferPoolStats(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	for i := 0; i < 3; i++ {
+		if err := fw.WriteFrame(0x01, []byte("pooled")); err != nil {
+			t.Fatalf("WriteFrame: %v", err)
+		}
+	}
+
+	before := BufferPoolStats()
+	fr := NewFrameReader(&buf, WithPayloadOwnership(OwnershipBorrow))
+	for i := 0; i < 3; i++ {
+		_, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame: %v", err)
+		}
+		if err := fr.Release(payload); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+	}
+	after := BufferPoolStats()
+
+	if got := (after.Hits + after.Misses) - (before.Hits + before.Misses); got < 3 {
+		t.Errorf("pool requests = %d; want at least 3", got)
+	}
+}
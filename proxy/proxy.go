@@ -0,0 +1,201 @@
+// Package proxy forwards enproto connections to upstream servers, decoding every
+// frame in transit so hooks can inspect, rewrite or drop it.
+//
+// Each accepted client gets its own upstream connection. Frames are pumped in both
+// directions until either side ends; hooks see them one at a time, in order, per
+// direction. For forwarding without hooks, enproto.CopyFrames avoids decoding.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Direction tells a Hook which way a frame is travelling.
+type Direction int
+
+const (
+	// ClientToUpstream is a frame sent by the client.
+	ClientToUpstream Direction = iota
+	// UpstreamToClient is a frame sent by the upstream server.
+	UpstreamToClient
+)
+
+// ErrDrop is returned by a Hook to drop a frame without ending the session.
+var ErrDrop = errors.New("proxy: drop frame")
+
+// Hook inspects a frame in transit. It may modify f in place, replacing its type or
+// payload. Returning ErrDrop drops the frame; any other error ends the session.
+type Hook func(dir Direction, f *enproto.Frame) error
+
+// Dialer opens the upstream connection for a new client. The context carries the
+// session's key; see SessionKey.
+type Dialer func(ctx context.Context) (net.Conn, error)
+
+type sessionKeyContextKey struct{}
+
+// SessionKey returns the key of the session being dialed: the result of the
+// WithSessionKey function, or the client's remote address without one.
+func SessionKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(sessionKeyContextKey{}).(string)
+	return key, ok
+}
+
+// Option configures a Proxy.
+type Option func(*Proxy)
+
+// WithHook adds a hook run on every frame in both directions. Hooks run in the order
+// they were added, each seeing the previous one's changes.
+func WithHook(h Hook) Option {
+	return func(p *Proxy) {
+		p.hooks = append(p.hooks, h)
+	}
+}
+
+// WithOnConnect sets a function called with the upstream writer before any client
+// frame is forwarded, for example to inject authentication. An error ends the session.
+func WithOnConnect(fn func(upstream *enproto.FrameWriter) error) Option {
+	return func(p *Proxy) {
+		p.onConnect = fn
+	}
+}
+
+// WithSessionKey makes the proxy read each client's first frame before dialing and
+// derive the session key from it, so that a sticky Dialer such as Rendezvous can send
+// every connection of one session to the same upstream. The first frame is then
+// forwarded through the hooks like any other.
+func WithSessionKey(key func(first enproto.Frame) string) Option {
+	return func(p *Proxy) {
+		p.sessionKey = key
+	}
+}
+
+// WithFramerOptions sets the enproto options used for both sides of every session.
+func WithFramerOptions(opts ...enproto.Option) Option {
+	return func(p *Proxy) {
+		p.framerOpts = opts
+	}
+}
+
+// Proxy accepts client connections and forwards them to upstreams.
+type Proxy struct {
+	dial       Dialer
+	hooks      []Hook
+	onConnect  func(*enproto.FrameWriter) error
+	sessionKey func(enproto.Frame) string
+	framerOpts []enproto.Option
+}
+
+// New returns a Proxy that opens an upstream connection with dial for every client.
+func New(dial Dialer, opts ...Option) *Proxy {
+	p := &Proxy{dial: dial}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Serve accepts clients from l and proxies each one on its own goroutine until l
+// fails, returning the Accept error. Session errors are dropped; use ServeConn to
+// observe them.
+func (p *Proxy) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go p.ServeConn(context.Background(), conn)
+	}
+}
+
+// ServeConn proxies a single client connection until either side ends, then closes
+// both. It returns nil when a side ends cleanly, or the first error otherwise.
+func (p *Proxy) ServeConn(ctx context.Context, client net.Conn) error {
+	defer client.Close()
+	cf := enproto.NewFramer(client, p.framerOpts...)
+
+	key := client.RemoteAddr().String()
+	var first *enproto.Frame
+	if p.sessionKey != nil {
+		msgType, payload, err := cf.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		first = &enproto.Frame{Type: msgType, Payload: payload}
+		key = p.sessionKey(*first)
+	}
+
+	upstream, err := p.dial(context.WithValue(ctx, sessionKeyContextKey{}, key))
+	if err != nil {
+		return err
+	}
+	defer upstream.Close()
+	uf := enproto.NewFramer(upstream, p.framerOpts...)
+
+	if p.onConnect != nil {
+		if err := p.onConnect(uf.FrameWriter); err != nil {
+			return err
+		}
+	}
+	if first != nil {
+		if err := p.forward(ClientToUpstream, uf.FrameWriter, first); err != nil {
+			return err
+		}
+	}
+	if err := uf.Flush(); err != nil {
+		return err
+	}
+
+	errc := make(chan error, 2)
+	go func() { errc <- p.pump(ClientToUpstream, uf.FrameWriter, cf.FrameReader) }()
+	go func() { errc <- p.pump(UpstreamToClient, cf.FrameWriter, uf.FrameReader) }()
+
+	err = <-errc
+	// Closing both ends unblocks the other pump.
+	client.Close()
+	upstream.Close()
+	<-errc
+	return err
+}
+
+// pump forwards frames from src to dst through the hooks until src ends.
+func (p *Proxy) pump(dir Direction, dst *enproto.FrameWriter, src *enproto.FrameReader) error {
+	for {
+		msgType, payload, err := src.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err := p.forward(dir, dst, &enproto.Frame{Type: msgType, Payload: payload}); err != nil {
+			return err
+		}
+		if src.ReadBuffered() == 0 {
+			if err := dst.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forward runs the hooks on f and buffers it for dst unless a hook dropped it.
+func (p *Proxy) forward(dir Direction, dst *enproto.FrameWriter, f *enproto.Frame) error {
+	for _, h := range p.hooks {
+		if err := h(dir, f); err != nil {
+			if errors.Is(err, ErrDrop) {
+				return nil
+			}
+			return err
+		}
+	}
+	return dst.WriteFrameBuffered(f.Type, f.Payload)
+}
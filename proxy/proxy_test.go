@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// echoUpstream returns a Dialer to an in-memory server that echoes every frame, and
+// a channel receiving the frames the server saw.
+func echoUpstream(t *testing.T) (Dialer, <-chan enproto.Frame) {
+	t.Helper()
+	seen := make(chan enproto.Frame, 16)
+	dial := func(context.Context) (net.Conn, error) {
+		proxySide, serverSide := net.Pipe()
+		go func() {
+			defer serverSide.Close()
+			fr := enproto.NewFramer(serverSide)
+			for {
+				msgType, payload, err := fr.ReadFrame()
+				if err != nil {
+					return
+				}
+				seen <- enproto.Frame{Type: msgType, Payload: payload}
+				if err := fr.WriteFrame(msgType, payload); err != nil {
+					return
+				}
+			}
+		}()
+		return proxySide, nil
+	}
+	return dial, seen
+}
+
+// TestProxy_Hooks verifies frames are rewritten, dropped and injected as configured.
+func TestProxy_Hooks(t *testing.T) {
+	dial, seen := echoUpstream(t)
+
+	p := New(dial,
+		WithOnConnect(func(up *enproto.FrameWriter) error {
+			return up.WriteFrameBuffered(0x10, []byte("token"))
+		}),
+		WithHook(func(dir Direction, f *enproto.Frame) error {
+			if dir == ClientToUpstream && f.Type == 0x01 {
+				f.Type = 0x02 // translate on the way in
+			}
+			if f.Type == 0x7F {
+				return ErrDrop
+			}
+			return nil
+		}),
+	)
+
+	clientSide, proxySide := net.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- p.ServeConn(context.Background(), proxySide) }()
+
+	client := enproto.NewFramer(clientSide)
+
+	if f := <-seen; f.Type != 0x10 || string(f.Payload) != "token" {
+		t.Errorf("upstream first saw %v; want injected auth frame", f)
+	}
+	// The echoed auth frame comes back to the client.
+	if msgType, _, err := client.ReadFrame(); err != nil || msgType != 0x10 {
+		t.Fatalf("client got type %d, %v; want echoed auth frame", msgType, err)
+	}
+
+	if err := client.WriteFrame(0x7F, []byte("secret")); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	if err := client.WriteFrame(0x01, []byte("hello")); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	if f := <-seen; f.Type != 0x02 || string(f.Payload) != "hello" {
+		t.Errorf("upstream saw %v; want translated type 2 %q", f, "hello")
+	}
+	if msgType, payload, err := client.ReadFrame(); err != nil || msgType != 0x02 || string(payload) != "hello" {
+		t.Errorf("client got %d %q, %v; want echoed type 2 %q", msgType, payload, err, "hello")
+	}
+
+	clientSide.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeConn = %v; want nil after client hangs up", err)
+	}
+}
+
+// TestProxy_HookError verifies a hook error ends the session with that error.
+func TestProxy_HookError(t *testing.T) {
+	dial, _ := echoUpstream(t)
+	errDenied := errors.New("denied")
+	p := New(dial, WithHook(func(Direction, *enproto.Frame) error { return errDenied }))
+
+	clientSide, proxySide := net.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- p.ServeConn(context.Background(), proxySide) }()
+
+	enproto.NewFramer(clientSide).WriteFrame(0x01, []byte("x"))
+	if err := <-done; !errors.Is(err, errDenied) {
+		t.Errorf("ServeConn = %v; want %v", err, errDenied)
+	}
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net"
+)
+
+// ErrNoUpstreams is returned by a Rendezvous dialer with no addresses.
+var ErrNoUpstreams = errors.New("proxy: no upstreams")
+
+// Rendezvous returns a Dialer that sends every session key to the same address in
+// addrs, using rendezvous (highest random weight) hashing. It picks the address with
+// the highest hash of key and address combined. Adding or removing an address only
+// moves the sessions that hash to it.
+func Rendezvous(addrs []string, dial func(ctx context.Context, addr string) (net.Conn, error)) Dialer {
+	return func(ctx context.Context) (net.Conn, error) {
+		if len(addrs) == 0 {
+			return nil, ErrNoUpstreams
+		}
+		key, _ := SessionKey(ctx)
+		return dial(ctx, pick(addrs, key))
+	}
+}
+
+// pick returns the address in addrs with the highest weight for key.
+func pick(addrs []string, key string) string {
+	var best string
+	var bestWeight uint64
+	for _, addr := range addrs {
+		if w := weight(key, addr); best == "" || w > bestWeight {
+			best, bestWeight = addr, w
+		}
+	}
+	return best
+}
+
+// weight is the rendezvous weight of addr for key: FNV-1a of key||0||addr, passed
+// through the MurmurHash3 finalizer. Raw FNV-1a leaves inputs that share a long
+// prefix too close together, which skews the spread across addresses.
+func weight(key, addr string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(addr))
+	return fmix64(h.Sum64())
+}
+
+// fmix64 is the 64-bit finalizer of MurmurHash3, which makes every input bit affect
+// every output bit.
+func fmix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestPick_Stable verifies keys keep their address and only move when it is removed.
+func TestPick_Stable(t *testing.T) {
+	addrs := []string{"a:1", "b:1", "c:1", "d:1"}
+	fewer := addrs[:3]
+
+	moved := 0
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("session-%d", i)
+		before := pick(addrs, key)
+		if again := pick(addrs, key); again != before {
+			t.Fatalf("pick(%q) = %q then %q; want stable", key, before, again)
+		}
+		after := pick(fewer, key)
+		if before != "d:1" && after != before {
+			t.Errorf("key %q moved from %q to %q though its address stayed", key, before, after)
+		}
+		if after != before {
+			moved++
+		}
+	}
+	if moved == 0 {
+		t.Errorf("no keys were on the removed address; hashing looks degenerate")
+	}
+}
+
+// TestPick_Distribution verifies keys spread evenly across addresses, including ones
+// that differ only in their last characters.
+func TestPick_Distribution(t *testing.T) {
+	const keys = 20000
+	for _, addrs := range [][]string{
+		{"a", "b", "c", "d"},
+		{"10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000", "10.0.0.4:9000"},
+	} {
+		counts := make(map[string]int)
+		for i := 0; i < keys; i++ {
+			counts[pick(addrs, fmt.Sprintf("session-%d", i))]++
+		}
+		want := keys / len(addrs)
+		for _, addr := range addrs {
+			if n := counts[addr]; n < want*9/10 || n > want*11/10 {
+				t.Errorf("%s got %d of %d keys; want %d ±10%%", addr, n, keys, want)
+			}
+		}
+	}
+}
+
+// TestProxy_SessionKey verifies the first frame selects the upstream and is still
+// forwarded.
+func TestProxy_SessionKey(t *testing.T) {
+	dialed := make(chan string, 1)
+	seen := make(chan enproto.Frame, 1)
+	dial := Rendezvous([]string{"x:1", "y:1"}, func(ctx context.Context, addr string) (net.Conn, error) {
+		dialed <- addr
+		proxySide, serverSide := net.Pipe()
+		go func() {
+			defer serverSide.Close()
+			msgType, payload, err := enproto.NewFramer(serverSide).ReadFrame()
+			if err == nil {
+				seen <- enproto.Frame{Type: msgType, Payload: payload}
+			}
+		}()
+		return proxySide, nil
+	})
+
+	p := New(dial, WithSessionKey(func(first enproto.Frame) string {
+		return string(first.Payload)
+	}))
+
+	clientSide, proxySide := net.Pipe()
+	defer clientSide.Close()
+	go p.ServeConn(context.Background(), proxySide)
+
+	if err := enproto.NewFramer(clientSide).WriteFrame(0x01, []byte("user-42")); err != nil {
+		t.Fatalf("WriteFrame: %v", err)
+	}
+	if addr, want := <-dialed, pick([]string{"x:1", "y:1"}, "user-42"); addr != want {
+		t.Errorf("dialed %q; want %q", addr, want)
+	}
+	if f := <-seen; string(f.Payload) != "user-42" {
+		t.Errorf("upstream got %q; want the first frame %q", f.Payload, "user-42")
+	}
+}
@@ -0,0 +1,220 @@
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ianchildress/enproto"
+)
+
+// defaultQueueSize is how many messages a subscriber may have waiting by default.
+const defaultQueueSize = 64
+
+// subscriber is the write side of one connection served by a Broker. Messages are
+// queued and written by the subscriber's own goroutine, so publishers never wait on
+// its connection.
+type subscriber struct {
+	fw     *enproto.FrameWriter
+	failed atomic.Bool // a write failed; later messages are not queued
+	done   chan struct{}
+
+	mu     sync.Mutex // guards sends on queue against close
+	queue  chan []byte
+	closed bool
+}
+
+func newSubscriber(fw *enproto.FrameWriter, queueSize int) *subscriber {
+	s := &subscriber{
+		fw:    fw,
+		queue: make(chan []byte, queueSize),
+		done:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// enqueue queues an encoded PUBLISH payload and reports whether there was room.
+func (s *subscriber) enqueue(payload []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.failed.Load() {
+		return false
+	}
+	select {
+	case s.queue <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// run writes queued messages, flushing whenever the queue drains, until the queue is
+// closed or a write fails.
+func (s *subscriber) run() {
+	defer close(s.done)
+	for payload := range s.queue {
+		if err := s.fw.WriteFrameBuffered(TypePublish, payload); err != nil {
+			s.failed.Store(true)
+			return
+		}
+		if len(s.queue) == 0 {
+			if err := s.fw.Flush(); err != nil {
+				s.failed.Store(true)
+				return
+			}
+		}
+	}
+}
+
+// close stops queueing and waits for the messages already queued to be written.
+func (s *subscriber) close() {
+	s.mu.Lock()
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+	<-s.done
+}
+
+// Option configures a Broker.
+type Option func(*Broker)
+
+// WithQueueSize sets how many messages may wait to be written to each subscriber.
+// Messages published while a subscriber's queue is full are dropped for that
+// subscriber alone. The default is 64.
+func WithQueueSize(n int) Option {
+	return func(b *Broker) {
+		b.queueSize = n
+	}
+}
+
+// Broker fans published messages out to the connections subscribed to their topic.
+// Every connection has its own queue and writer goroutine, so a slow or stalled
+// subscriber misses messages rather than holding up publishers and other subscribers.
+//
+// The zero value is an empty Broker ready to use.
+type Broker struct {
+	queueSize int
+
+	mu     sync.RWMutex
+	topics map[string]map[*subscriber]struct{}
+}
+
+// NewBroker returns an empty Broker configured by opts.
+func NewBroker(opts ...Option) *Broker {
+	b := &Broker{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// ServeConn handles control frames from fr until the connection ends, returning nil
+// on a clean end of stream. Frames of other types fail with enproto.ErrNoHandler.
+// The connection's subscriptions are removed when ServeConn returns, after the
+// messages already queued for it have been written. The Broker is the only writer of
+// fr while ServeConn runs. fr must use the default OwnershipCopy mode, as published
+// payloads are queued for subscribers after their frame has been handled.
+func (b *Broker) ServeConn(fr *enproto.Framer) error {
+	queueSize := b.queueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	sub := newSubscriber(fr.FrameWriter, queueSize)
+	subscribed := make(map[string]struct{})
+	defer func() {
+		for topic := range subscribed {
+			b.unsubscribe(topic, sub)
+		}
+		sub.close()
+	}()
+
+	rt := enproto.NewRouter()
+	rt.Handle(TypeSubscribe, enproto.HandlerFunc(func(_ byte, payload []byte) error {
+		topic, _, err := decode(payload)
+		if err != nil {
+			return err
+		}
+		subscribed[topic] = struct{}{}
+		b.subscribe(topic, sub)
+		return nil
+	}))
+	rt.Handle(TypeUnsubscribe, enproto.HandlerFunc(func(_ byte, payload []byte) error {
+		topic, _, err := decode(payload)
+		if err != nil {
+			return err
+		}
+		delete(subscribed, topic)
+		b.unsubscribe(topic, sub)
+		return nil
+	}))
+	rt.Handle(TypePublish, enproto.HandlerFunc(func(_ byte, payload []byte) error {
+		topic, _, err := decode(payload)
+		if err != nil {
+			return err
+		}
+		b.fanOut(topic, payload)
+		return nil
+	}))
+
+	return rt.Serve(fr.FrameReader)
+}
+
+// Publish queues data for every current subscriber of topic and returns how many
+// subscribers had room for it. It does not wait for the messages to be written.
+func (b *Broker) Publish(topic string, data []byte) (int, error) {
+	payload, err := encode(topic, data)
+	if err != nil {
+		return 0, err
+	}
+	return b.fanOut(topic, payload), nil
+}
+
+// fanOut queues an encoded PUBLISH payload for each subscriber of topic. A subscriber
+// whose write failed takes no more messages; its own ServeConn loop sees the broken
+// connection and removes its subscriptions.
+func (b *Broker) fanOut(topic string, payload []byte) int {
+	b.mu.RLock()
+	subs := make([]*subscriber, 0, len(b.topics[topic]))
+	for sub := range b.topics[topic] {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	var queued int
+	for _, sub := range subs {
+		if sub.enqueue(payload) {
+			queued++
+		}
+	}
+	return queued
+}
+
+func (b *Broker) subscribe(topic string, sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.topics == nil {
+		b.topics = make(map[string]map[*subscriber]struct{})
+	}
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[*subscriber]struct{})
+	}
+	b.topics[topic][sub] = struct{}{}
+}
+
+func (b *Broker) unsubscribe(topic string, sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.topics[topic], sub)
+	if len(b.topics[topic]) == 0 {
+		delete(b.topics, topic)
+	}
+}
+
+// Subscribers returns the number of connections currently subscribed to topic.
+func (b *Broker) Subscribers(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.topics[topic])
+}
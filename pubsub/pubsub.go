@@ -0,0 +1,94 @@
+// Package pubsub implements topic-based publish/subscribe over enproto frames.
+//
+// Clients send SUBSCRIBE, UNSUBSCRIBE and PUBLISH control frames; a Broker tracks
+// which connections subscribe to which topics and fans each published message out
+// to the subscribers as a PUBLISH frame. The control frame types are reserved on
+// pubsub connections and must not be used for application frames there.
+//
+// Every control payload starts with the topic: [2B topic length][topic], big-endian,
+// followed for PUBLISH by the message data.
+package pubsub
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ianchildress/enproto"
+	"github.com/ianchildress/enproto/internal/bufpool"
+)
+
+// Control frame types.
+const (
+	TypeSubscribe   byte = 0xF0
+	TypeUnsubscribe byte = 0xF1
+	TypePublish     byte = 0xF2
+)
+
+var (
+	ErrTopicTooLong = errors.New("pubsub: topic too long")
+	ErrMalformed    = errors.New("pubsub: malformed control frame")
+)
+
+// encode builds a control payload carrying topic followed by data.
+func encode(topic string, data []byte) ([]byte, error) {
+	return encodeInto(make([]byte, 2+len(topic)+len(data)), topic, data)
+}
+
+// encodeInto is encode writing into payload, which must hold 2+len(topic)+len(data) bytes.
+func encodeInto(payload []byte, topic string, data []byte) ([]byte, error) {
+	if len(topic) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTopicTooLong, len(topic))
+	}
+	binary.BigEndian.PutUint16(payload[0:2], uint16(len(topic)))
+	copy(payload[2:], topic)
+	copy(payload[2+len(topic):], data)
+	return payload, nil
+}
+
+// decode splits a control payload into its topic and trailing data.
+// The returned data aliases payload.
+func decode(payload []byte) (topic string, data []byte, err error) {
+	if len(payload) < 2 {
+		return "", nil, ErrMalformed
+	}
+	n := int(binary.BigEndian.Uint16(payload[0:2]))
+	if len(payload) < 2+n {
+		return "", nil, ErrMalformed
+	}
+	return string(payload[2 : 2+n]), payload[2+n:], nil
+}
+
+// Subscribe asks the broker on the other end of w to deliver messages published to topic.
+func Subscribe(w *enproto.FrameWriter, topic string) error {
+	return writeControl(w, TypeSubscribe, topic, nil)
+}
+
+// Unsubscribe cancels an earlier Subscribe to topic.
+func Unsubscribe(w *enproto.FrameWriter, topic string) error {
+	return writeControl(w, TypeUnsubscribe, topic, nil)
+}
+
+// Publish sends data to every subscriber of topic.
+func Publish(w *enproto.FrameWriter, topic string, data []byte) error {
+	return writeControl(w, TypePublish, topic, data)
+}
+
+// writeControl sends a control frame. WriteFrame is done with the payload once it
+// returns, so the payload is built in a pooled buffer.
+func writeControl(w *enproto.FrameWriter, msgType byte, topic string, data []byte) error {
+	buf := bufpool.Get(2 + len(topic) + len(data))
+	defer bufpool.Put(buf)
+	payload, err := encodeInto(buf, topic, data)
+	if err != nil {
+		return err
+	}
+	return w.WriteFrame(msgType, payload)
+}
+
+// ParsePublish decodes the payload of a PUBLISH frame delivered by a broker.
+// The returned data aliases payload.
+func ParsePublish(payload []byte) (topic string, data []byte, err error) {
+	return decode(payload)
+}
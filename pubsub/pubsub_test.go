@@ -0,0 +1,214 @@
+package pubsub
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestEncodeDecode verifies control payloads round-trip.
+func TestEncodeDecode(t *testing.T) {
+	payload, err := encode("sensors/temp", []byte("21.5"))
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+
+	topic, data, err := ParsePublish(payload)
+	if err != nil {
+		t.Fatalf("ParsePublish error: %v", err)
+	}
+	if topic != "sensors/temp" || string(data) != "21.5" {
+		t.Errorf("decoded %q %q; want %q %q", topic, data, "sensors/temp", "21.5")
+	}
+
+	if _, _, err := ParsePublish([]byte{0x00, 0x05, 'a'}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+	if _, err := encode(string(make([]byte, 1<<16)), nil); !errors.Is(err, ErrTopicTooLong) {
+		t.Errorf("expected ErrTopicTooLong, got %v", err)
+	}
+}
+
+// TestBroker_ServeConn verifies subscribe, fan-out and unsubscribe over a connection.
+func TestBroker_ServeConn(t *testing.T) {
+	in := &bytes.Buffer{}
+	out := &bytes.Buffer{}
+	client := enproto.NewFramerRW(out, in)
+	server := enproto.NewFramerRW(in, out)
+
+	if err := Subscribe(client.FrameWriter, "news"); err != nil {
+		t.Fatalf("Subscribe error: %v", err)
+	}
+	if err := Publish(client.FrameWriter, "news", []byte("hello")); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	if err := Publish(client.FrameWriter, "other", []byte("ignored")); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+	if err := Unsubscribe(client.FrameWriter, "news"); err != nil {
+		t.Fatalf("Unsubscribe error: %v", err)
+	}
+	if err := Publish(client.FrameWriter, "news", []byte("after unsubscribe")); err != nil {
+		t.Fatalf("Publish error: %v", err)
+	}
+
+	b := NewBroker()
+	if err := b.ServeConn(server); err != nil {
+		t.Fatalf("ServeConn error: %v", err)
+	}
+
+	msgType, payload, err := client.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if msgType != TypePublish {
+		t.Errorf("message type = %#x; want %#x", msgType, TypePublish)
+	}
+	topic, data, err := ParsePublish(payload)
+	if err != nil {
+		t.Fatalf("ParsePublish error: %v", err)
+	}
+	if topic != "news" || string(data) != "hello" {
+		t.Errorf("delivered %q %q; want %q %q", topic, data, "news", "hello")
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("expected exactly one delivery, %d bytes left", out.Len())
+	}
+	if n := b.Subscribers("news"); n != 0 {
+		t.Errorf("subscribers after unsubscribe = %d; want 0", n)
+	}
+}
+
+// TestBroker_Publish verifies server-side publishing and cleanup when a connection ends.
+func TestBroker_Publish(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	client := enproto.NewFramer(clientConn)
+
+	b := NewBroker()
+	done := make(chan error, 1)
+	go func() { done <- b.ServeConn(enproto.NewFramer(serverConn)) }()
+
+	if err := Subscribe(client.FrameWriter, "alerts"); err != nil {
+		t.Fatalf("Subscribe error: %v", err)
+	}
+	for b.Subscribers("alerts") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	published := make(chan int, 1)
+	go func() {
+		n, _ := b.Publish("alerts", []byte("from server"))
+		published <- n
+	}()
+
+	_, payload, err := client.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if topic, data, _ := ParsePublish(payload); topic != "alerts" || string(data) != "from server" {
+		t.Errorf("delivered %q %q; want %q %q", topic, data, "alerts", "from server")
+	}
+	if n := <-published; n != 1 {
+		t.Errorf("Publish delivered to %d subscribers; want 1", n)
+	}
+
+	clientConn.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeConn error: %v", err)
+	}
+	if n := b.Subscribers("alerts"); n != 0 {
+		t.Errorf("subscribers after connection ended = %d; want 0", n)
+	}
+}
+
+// TestBroker_UnexpectedType ensures application frames are refused on pubsub connections.
+func TestBroker_UnexpectedType(t *testing.T) {
+	in := &bytes.Buffer{}
+	server := enproto.NewFramerRW(in, &bytes.Buffer{})
+	if err := enproto.NewFrameWriter(in).WriteFrame(0x01, []byte("app frame")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	if err := NewBroker().ServeConn(server); !errors.Is(err, enproto.ErrNoHandler) {
+		t.Errorf("expected ErrNoHandler, got %v", err)
+	}
+}
+
+// blockedConn is a transport whose writes block until release is closed. entered is
+// closed when the first write starts.
+type blockedConn struct {
+	entered chan struct{}
+	once    *sync.Once
+	release chan struct{}
+}
+
+func (c blockedConn) Write(p []byte) (int, error) {
+	c.once.Do(func() { close(c.entered) })
+	<-c.release
+	return len(p), nil
+}
+
+// TestBroker_SlowSubscriber ensures a stalled subscriber does not hold up publishing
+// to the others, and only misses messages once its queue is full.
+func TestBroker_SlowSubscriber(t *testing.T) {
+	b := NewBroker(WithQueueSize(4))
+	subscribe := func(w io.Writer) (*io.PipeWriter, chan error) {
+		r, cw := io.Pipe()
+		done := make(chan error, 1)
+		go func() { done <- b.ServeConn(enproto.NewFramerRW(r, w)) }()
+		if err := Subscribe(enproto.NewFrameWriter(cw), "ticks"); err != nil {
+			t.Fatal(err)
+		}
+		return cw, done
+	}
+
+	stalled := blockedConn{entered: make(chan struct{}), once: new(sync.Once), release: make(chan struct{})}
+	fast := &bytes.Buffer{}
+	stalledIn, stalledDone := subscribe(stalled)
+	fastIn, fastDone := subscribe(fast)
+	for b.Subscribers("ticks") < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 4; i++ {
+		if n, err := b.Publish("ticks", []byte("tick")); err != nil || n != 2 {
+			t.Fatalf("Publish = %d, %v; want both subscribers", n, err)
+		}
+	}
+
+	fastIn.Close()
+	if err := <-fastDone; err != nil {
+		t.Fatalf("ServeConn error: %v", err)
+	}
+	fr := enproto.NewFrameReader(fast)
+	for i := 0; i < 4; i++ {
+		if _, _, err := fr.ReadFrame(); err != nil {
+			t.Fatalf("fast subscriber got %d messages: %v", i, err)
+		}
+	}
+
+	// The stalled subscriber's writer is stuck flushing, so its queue fills and
+	// further messages are dropped for it rather than blocking Publish.
+	<-stalled.entered
+	var queued int
+	for i := 0; i < 20; i++ {
+		n, _ := b.Publish("ticks", []byte("tick"))
+		queued += n
+	}
+	if queued > 4 {
+		t.Errorf("stalled subscriber queued %d more messages; want at most its queue size of 4", queued)
+	}
+
+	close(stalled.release)
+	stalledIn.Close()
+	if err := <-stalledDone; err != nil {
+		t.Fatalf("ServeConn error: %v", err)
+	}
+}
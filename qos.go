@@ -0,0 +1,197 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrUnknownClass is wrapped by QoSWriter errors for frames sent to a class it
+	// was not created with.
+	ErrUnknownClass = errors.New("unknown QoS class")
+
+	// ErrQoSWriterClosed is returned for frames sent after QoSWriter.Close.
+	ErrQoSWriterClosed = errors.New("QoS writer closed")
+)
+
+// qosQuantum is the number of bytes a class of weight 1 may send per scheduling round.
+const qosQuantum = 4096
+
+// QoSClass describes a traffic class of a QoSWriter, such as "control",
+// "interactive" or "bulk".
+type QoSClass struct {
+	Name string
+	// Weight is the class's share of the connection relative to the other classes
+	// while they all have frames waiting. Values below 1 count as 1.
+	Weight int
+	// Queue is how many frames may wait in the class before WriteFrame blocks.
+	// Values below 1 count as 1.
+	Queue int
+}
+
+// QoSWriter shares one FrameWriter between traffic classes by weighted fair
+// queuing: when several classes have frames waiting, each gets bandwidth in
+// proportion to its weight, measured in bytes, so a bulk class cannot starve an
+// interactive one. Frames within a class keep their order. A single goroutine writes
+// the frames and flushes whenever every queue is empty.
+//
+// QoSWriter is safe for concurrent use and must be the only writer of its FrameWriter.
+type QoSWriter struct {
+	fw *FrameWriter
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	classes  []*qosClass
+	byName   map[string]*qosClass
+	queued   int
+	next     int  // class being visited by the scheduler
+	visiting bool // whether classes[next] has had its quantum for this visit
+	closed   bool
+	err      error // first write error
+
+	done chan struct{}
+}
+
+type qosClass struct {
+	quantum int
+	limit   int
+	frames  []Frame
+	deficit int
+}
+
+// NewQoSWriter returns a QoSWriter over fw with the given classes and starts its
+// writer goroutine. Call Close to stop it.
+func NewQoSWriter(fw *FrameWriter, classes ...QoSClass) *QoSWriter {
+	q := &QoSWriter{
+		fw:     fw,
+		byName: make(map[string]*qosClass, len(classes)),
+		done:   make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	for _, c := range classes {
+		qc := &qosClass{
+			quantum: max(c.Weight, 1) * qosQuantum,
+			limit:   max(c.Queue, 1),
+		}
+		q.classes = append(q.classes, qc)
+		q.byName[c.Name] = qc
+	}
+	go q.run()
+	return q
+}
+
+// WriteFrame queues a frame in class, blocking while the class's queue is full. It
+// returns the writer goroutine's error if an earlier write failed. The payload is
+// written later and must not be modified afterwards.
+func (q *QoSWriter) WriteFrame(class string, msgType byte, payload []byte) error {
+	if uint64(len(payload)) > uint64(q.fw.cfg.maxFrameSize) {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, len(payload))
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c, ok := q.byName[class]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownClass, class)
+	}
+	for len(c.frames) >= c.limit && !q.closed && q.err == nil {
+		q.cond.Wait()
+	}
+	if q.err != nil {
+		return q.err
+	}
+	if q.closed {
+		return ErrQoSWriterClosed
+	}
+
+	c.frames = append(c.frames, Frame{Type: msgType, Payload: payload})
+	q.queued++
+	q.cond.Broadcast()
+	return nil
+}
+
+// Close writes the frames still queued, stops the writer goroutine and returns the
+// first write error, if any.
+func (q *QoSWriter) Close() error {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+	return q.err
+}
+
+// run writes frames in scheduling order until the writer is closed and drained.
+func (q *QoSWriter) run() {
+	defer close(q.done)
+
+	for {
+		q.mu.Lock()
+		for q.queued == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.queued == 0 {
+			q.mu.Unlock()
+			return
+		}
+		f := q.pick()
+		idle := q.queued == 0
+		q.cond.Broadcast() // a queue has room again
+		q.mu.Unlock()
+
+		err := q.fw.WriteFrameBuffered(f.Type, f.Payload)
+		if err == nil && idle {
+			err = q.fw.Flush()
+		}
+		if err != nil {
+			q.mu.Lock()
+			q.err = err
+			q.cond.Broadcast()
+			q.mu.Unlock()
+			return
+		}
+	}
+}
+
+// pick dequeues the next frame by deficit round robin. q.mu must be held and at
+// least one frame queued.
+func (q *QoSWriter) pick() Frame {
+	for {
+		c := q.classes[q.next]
+		if len(c.frames) == 0 {
+			c.deficit = 0
+			q.advance()
+			continue
+		}
+		if !q.visiting {
+			c.deficit += c.quantum
+			q.visiting = true
+		}
+
+		f := c.frames[0]
+		cost := HeaderSize + len(f.Payload)
+		if cost > c.deficit {
+			q.advance()
+			continue
+		}
+
+		c.deficit -= cost
+		c.frames[0] = Frame{}
+		c.frames = c.frames[1:]
+		q.queued--
+		if len(c.frames) == 0 {
+			c.deficit = 0
+			q.advance()
+		}
+		return f
+	}
+}
+
+// advance moves the scheduler on to the next class.
+func (q *QoSWriter) advance() {
+	q.next = (q.next + 1) % len(q.classes)
+	q.visiting = false
+}
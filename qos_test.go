@@ -0,0 +1,119 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestQoSWriter_Weights verifies backlogged classes share the connection by weight.
+func TestQoSWriter_Weights(t *testing.T) {
+	var out lockedBuffer
+	release := make(chan struct{})
+	w := io.MultiWriter(blockWriter{release}, &out)
+	q := NewQoSWriter(NewFrameWriter(w, WithWriteBufferSize(16)),
+		QoSClass{Name: "interactive", Weight: 3, Queue: 64},
+		QoSClass{Name: "bulk", Weight: 1, Queue: 64},
+	)
+
+	// Each frame costs exactly one quantum.
+	payload := make([]byte, qosQuantum-HeaderSize)
+	for i := 0; i < 40; i++ {
+		if err := q.WriteFrame("bulk", 0x2, payload); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.WriteFrame("interactive", 0x1, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewFrameReader(bytes.NewReader(out.Bytes()))
+	var types []byte
+	for {
+		msgType, _, err := r.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, msgType)
+	}
+	if len(types) != 80 {
+		t.Fatalf("read %d frames; want 80", len(types))
+	}
+
+	// The first frame may have been written before the others were queued.
+	interactive := bytes.Count(types[1:41], []byte{0x1})
+	if interactive < 28 || interactive > 32 {
+		t.Errorf("%d of 40 frames were interactive; want about 30", interactive)
+	}
+}
+
+// TestQoSWriter_Order verifies frames within a class keep their order.
+func TestQoSWriter_Order(t *testing.T) {
+	var out lockedBuffer
+	q := NewQoSWriter(NewFrameWriter(&out), QoSClass{Name: "control"}, QoSClass{Name: "bulk", Queue: 4})
+	for i := 0; i < 10; i++ {
+		if err := q.WriteFrame("bulk", 0x2, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.WriteFrame("control", 0x1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewFrameReader(bytes.NewReader(out.Bytes()))
+	next := 0
+	for {
+		msgType, payload, err := r.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msgType == 0x2 {
+			if payload[0] != byte(next) {
+				t.Fatalf("bulk frame %d arrived at position %d", payload[0], next)
+			}
+			next++
+		}
+	}
+	if next != 10 {
+		t.Errorf("read %d bulk frames; want 10", next)
+	}
+}
+
+// TestQoSWriter_Errors verifies unknown classes, write failures and writes after Close.
+func TestQoSWriter_Errors(t *testing.T) {
+	wantErr := errors.New("broken pipe")
+	q := NewQoSWriter(NewFrameWriter(failWriter{wantErr}), QoSClass{Name: "bulk"})
+
+	if err := q.WriteFrame("video", 0x1, nil); !errors.Is(err, ErrUnknownClass) {
+		t.Errorf("expected ErrUnknownClass, got %v", err)
+	}
+	if err := q.WriteFrame("bulk", 0x1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); !errors.Is(err, wantErr) {
+		t.Errorf("Close returned %v; want %v", err, wantErr)
+	}
+	if err := q.WriteFrame("bulk", 0x1, nil); !errors.Is(err, wantErr) {
+		t.Errorf("WriteFrame after failure returned %v; want %v", err, wantErr)
+	}
+
+	q = NewQoSWriter(NewFrameWriter(io.Discard), QoSClass{Name: "bulk"})
+	q.Close()
+	if err := q.WriteFrame("bulk", 0x1, nil); !errors.Is(err, ErrQoSWriterClosed) {
+		t.Errorf("expected ErrQoSWriterClosed, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package enproto
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// FrameReader reads our length‐prefixed, versioned frames from an io.Reader.
+type FrameReader struct {
+	src io.Reader // the transport, for copies that bypass br
+	br  *bufio.Reader
+	dl  readDeadliner // the transport, if it supports read deadlines
+
+	rbuf []byte // reusable read payload buffer
+
+	loans loans // payloads lent in OwnershipBorrow mode
+
+	cfg config
+}
+
+// NewFrameReader wraps r with the read half of our framing logic, configured by opts.
+func NewFrameReader(r io.Reader, opts ...Option) *FrameReader {
+	return newFrameReader(r, newConfig(opts))
+}
+
+func newFrameReader(r io.Reader, cfg config) *FrameReader {
+	fr := &FrameReader{
+		src: r,
+		br:  bufio.NewReaderSize(r, cfg.readBufferSize),
+		cfg: cfg,
+	}
+	fr.dl, _ = r.(readDeadliner)
+	fr.loans.alloc = cfg.allocator
+	fr.loans.zero = cfg.zeroOnRelease
+	if fr.loans.alloc == nil {
+		fr.loans.alloc = PoolAllocator()
+	}
+	return fr
+}
+
+// ReadFrame reads the next frame from the underlying reader and validates the protocol header.
+// It returns the message type and a payload slice.
+//
+// Note: By default this function allocates a new byte slice for the payload on every call,
+// making it safe for the caller to retain or mutate the returned data indefinitely.
+// With WithPayloadOwnership(OwnershipBorrow) the payload is instead lent from a pool
+// and must be handed back with Release. WithAllocator replaces where either kind of
+// payload buffer comes from.
+func (r *FrameReader) ReadFrame() (msgType byte, payload []byte, err error) {
+	msgType, length, err := r.readHeader()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if r.cfg.ownership == OwnershipBorrow {
+		return r.readBorrowedPayload(msgType, length)
+	}
+
+	// Explicitly allocate a new slice to hold the incoming data.
+	// This ensures that the returned payload is independent of any internal framer buffers.
+	if r.cfg.allocator != nil {
+		payload = r.cfg.allocator.Alloc(int(length))
+	} else {
+		payload = make([]byte, length)
+	}
+	if err = r.readPayload(payload); err != nil {
+		if r.cfg.allocator != nil {
+			r.cfg.allocator.Free(payload)
+		}
+		return 0, nil, err
+	}
+
+	return msgType, payload, nil
+}
+
+// readBorrowed reads the next frame into a buffer lent from r.loans.
+func (r *FrameReader) readBorrowed() (msgType byte, payload []byte, err error) {
+	msgType, length, err := r.readHeader()
+	if err != nil {
+		return 0, nil, err
+	}
+	return r.readBorrowedPayload(msgType, length)
+}
+
+// readBorrowedPayload reads a length-byte payload into a lent buffer.
+func (r *FrameReader) readBorrowedPayload(msgType byte, length uint32) (byte, []byte, error) {
+	payload := r.loans.borrow(int(length))
+	if err := r.readPayload(payload); err != nil {
+		r.loans.release(payload)
+		return 0, nil, err
+	}
+	return msgType, payload, nil
+}
+
+// readHeader reads the next frame header, validates it against the configured
+// magic, accepted versions and size limit, and applies the configured Filter.
+func (r *FrameReader) readHeader() (msgType byte, length uint32, err error) {
+	h, err := r.filteredHeader()
+	if err != nil {
+		return 0, 0, err
+	}
+	return h.Type, h.Length, nil
+}
+
+// nextHeader consumes and validates the next frame header.
+func (r *FrameReader) nextHeader() (Header, error) {
+	var header [HeaderSize]byte
+	if _, err := io.ReadFull(r.br, header[:]); err != nil {
+		return Header{}, err
+	}
+
+	h := decodeHeader(header[:])
+	if err := r.cfg.validateHeader(h); err != nil {
+		return Header{}, err
+	}
+	r.cfg.counters.read(h.Length)
+	return h, nil
+}
+
+// PeekFrameHeader returns the next frame's validated header while leaving the frame,
+// header included, unread. Routers can use it to decide whether to read, skip or
+// proxy a payload before allocating for it; a following ReadFrame returns that frame.
+func (r *FrameReader) PeekFrameHeader() (Header, error) {
+	b, err := r.br.Peek(HeaderSize)
+	if err != nil {
+		// Match io.ReadFull: EOF mid-header is unexpected.
+		if errors.Is(err, io.EOF) && len(b) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return Header{}, err
+	}
+
+	h := decodeHeader(b)
+	if err := r.cfg.validateHeader(h); err != nil {
+		return Header{}, err
+	}
+	return h, nil
+}
+
+// SkipFrame reads and validates the next header, then discards its payload without
+// allocating for it. It returns the skipped frame's header.
+func (r *FrameReader) SkipFrame() (Header, error) {
+	h, err := r.nextHeader()
+	if err != nil {
+		return Header{}, err
+	}
+
+	if err := r.discardPayload(h); err != nil {
+		return Header{}, err
+	}
+	return h, nil
+}
+
+// discardPayload drops the payload that follows h.
+func (r *FrameReader) discardPayload(h Header) error {
+	return r.budgeted(int(h.Length), func() error {
+		if _, err := r.br.Discard(int(h.Length)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// validateHeader checks protocol constraints to avoid processing malformed data.
+func (c *config) validateHeader(h Header) error {
+	if h.Magic != c.magic {
+		return ErrBadMagic
+	}
+	if !c.acceptsVersion(h.Version) {
+		return ErrBadVersion
+	}
+	if h.Length > c.maxFrameSize {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, h.Length)
+	}
+	return nil
+}
+
+// ReadFrameSharedBuffer reads the next frame, validates header, and returns msgType + payload.
+// NOTE: payload is backed by an internal reusable buffer and is only valid until
+// the next ReadFrameSharedBuffer call on this FrameReader.
+func (r *FrameReader) ReadFrameSharedBuffer() (msgType byte, payload []byte, err error) {
+	msgType, n, err := r.readHeader()
+	if err != nil {
+		return 0, nil, err
+	}
+	length := int(n)
+
+	// Ensure reusable buffer is large enough.
+	if cap(r.rbuf) < length {
+		// Grow to at least length; optionally over-allocate to reduce future grows.
+		// This is a normal heap allocation but happens rarely (only when size increases).
+		newCap := cap(r.rbuf) * 2
+		if newCap < length {
+			newCap = length
+		}
+		r.rbuf = make([]byte, newCap)
+	}
+
+	payload = r.rbuf[:length]
+	if err = r.readPayload(payload); err != nil {
+		return 0, nil, err
+	}
+	return msgType, payload, nil
+}
+
+// ReadBuffered returns the number of bytes currently buffered and ready to be read
+// without reading from the underlying io.Reader.
+func (r *FrameReader) ReadBuffered() int {
+	if r == nil || r.br == nil {
+		return 0
+	}
+	return r.br.Buffered()
+}
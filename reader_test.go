@@ -0,0 +1,122 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestFrameReader_ReadOnly verifies a FrameReader works over a plain io.Reader.
+func TestFrameReader_ReadOnly(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewFrameWriter(buf).WriteFrame(0x6, []byte("read only")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	fr := NewFrameReader(io.MultiReader(buf))
+
+	gotType, gotPayload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if gotType != 0x6 {
+		t.Errorf("message type = %d; want %d", gotType, 0x6)
+	}
+	if !bytes.Equal(gotPayload, []byte("read only")) {
+		t.Errorf("payload = %q; want %q", gotPayload, "read only")
+	}
+
+	if _, _, err := fr.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+// TestFrameReader_PeekFrameHeader verifies peeking leaves the frame for ReadFrame.
+func TestFrameReader_PeekFrameHeader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf)
+
+	payload := []byte("peeked payload")
+	if err := fr.WriteFrame(0x9, payload); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		h, err := fr.PeekFrameHeader()
+		if err != nil {
+			t.Fatalf("PeekFrameHeader error: %v", err)
+		}
+		want := Header{Magic: Magic, Version: ProtocolVersion, Type: 0x9, Length: uint32(len(payload))}
+		if h != want {
+			t.Errorf("header = %+v; want %+v", h, want)
+		}
+	}
+
+	gotType, gotPayload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if gotType != 0x9 || !bytes.Equal(gotPayload, payload) {
+		t.Errorf("frame = %d %q; want %d %q", gotType, gotPayload, 0x9, payload)
+	}
+}
+
+// TestFrameReader_PeekFrameHeader_Errors covers truncated and invalid headers.
+func TestFrameReader_PeekFrameHeader_Errors(t *testing.T) {
+	if _, err := NewFrameReader(&bytes.Buffer{}).PeekFrameHeader(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF on empty stream, got %v", err)
+	}
+
+	truncated := bytes.NewBuffer([]byte{0x59, 0x59, ProtocolVersion})
+	if _, err := NewFrameReader(truncated).PeekFrameHeader(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF on truncated header, got %v", err)
+	}
+
+	badMagic := bytes.NewBuffer([]byte{0xFF, 0xFF, ProtocolVersion, 0x1, 0, 0, 0, 0})
+	if _, err := NewFrameReader(badMagic).PeekFrameHeader(); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("expected ErrBadMagic, got %v", err)
+	}
+}
+
+// TestFrameReader_SkipFrame verifies a skipped payload is discarded and the next frame is intact.
+func TestFrameReader_SkipFrame(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf)
+
+	if err := fr.WriteFrame(0x1, []byte("unwanted")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if err := fr.WriteFrame(0x2, []byte("wanted")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	h, err := fr.SkipFrame()
+	if err != nil {
+		t.Fatalf("SkipFrame error: %v", err)
+	}
+	if h.Type != 0x1 || h.Length != uint32(len("unwanted")) {
+		t.Errorf("skipped header = %+v; want type 1, length %d", h, len("unwanted"))
+	}
+
+	gotType, gotPayload, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if gotType != 0x2 || string(gotPayload) != "wanted" {
+		t.Errorf("frame = %d %q; want %d %q", gotType, gotPayload, 0x2, "wanted")
+	}
+}
+
+// TestFrameReader_SkipFrame_Truncated ensures a short payload is reported as unexpected EOF.
+func TestFrameReader_SkipFrame_Truncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewFrameWriter(buf).WriteFrame(0x1, []byte("truncated")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	buf.Truncate(buf.Len() - 2)
+
+	if _, err := NewFrameReader(buf).SkipFrame(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
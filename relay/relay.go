@@ -0,0 +1,153 @@
+// Package relay forwards selected enproto frames between connected peers so that
+// nodes can form simple mesh topologies.
+//
+// Relayed frames keep their message type but carry an envelope in their payload:
+// [16B message ID][1B hops remaining][data]. Each node delivers a relayed frame locally
+// once, then forwards it to every other peer while hops remain. A cache of recently
+// seen message IDs suppresses loops.
+package relay
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/ianchildress/enproto"
+	"github.com/ianchildress/enproto/internal/seen"
+)
+
+// ID uniquely identifies a relayed message across the mesh.
+type ID [16]byte
+
+const envelopeSize = len(ID{}) + 1
+
+// ErrMalformed is returned for relayed frames too short to hold an envelope.
+var ErrMalformed = errors.New("relay: malformed envelope")
+
+// Option configures a Relay.
+type Option func(*Relay)
+
+// WithTypes selects the message types that are relayed. Frames of other types are
+// delivered locally without an envelope and never forwarded.
+func WithTypes(types ...byte) Option {
+	return func(r *Relay) {
+		for _, t := range types {
+			r.types[t] = true
+		}
+	}
+}
+
+// WithMaxHops sets the hop budget of originated messages. The default is 8.
+func WithMaxHops(hops byte) Option {
+	return func(r *Relay) {
+		r.maxHops = hops
+	}
+}
+
+// WithSeenTTL sets how long message IDs are remembered for loop suppression.
+// The default is one minute.
+func WithSeenTTL(ttl time.Duration) Option {
+	return func(r *Relay) {
+		r.seen.TTL = ttl
+	}
+}
+
+// Relay delivers frames from its peers locally and forwards relayed types onwards.
+type Relay struct {
+	hub     *enproto.Hub
+	deliver enproto.Handler
+	types   [256]bool
+	maxHops byte
+	seen    seen.Set[ID]
+}
+
+// New returns a Relay that writes to peers through hub and hands every frame it
+// accepts to deliver. For relayed types, deliver receives the data without the envelope.
+func New(hub *enproto.Hub, deliver enproto.Handler, opts ...Option) *Relay {
+	r := &Relay{
+		hub:     hub,
+		deliver: deliver,
+		maxHops: 8,
+		seen:    seen.Set[ID]{TTL: time.Minute},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Originate sends a new message of a relayed type to every peer and returns its ID.
+func (r *Relay) Originate(msgType byte, data []byte) (ID, error) {
+	var id ID
+	if _, err := rand.Read(id[:]); err != nil {
+		return ID{}, err
+	}
+	r.seen.Add(id, time.Now())
+	r.hub.Broadcast(msgType, wrap(id, r.maxHops, data))
+	return id, nil
+}
+
+// ServePeer registers fr's writer with the hub and processes frames from it until the
+// connection ends, returning nil on a clean end of stream. The peer is removed from
+// the hub when ServePeer returns.
+//
+// Each payload is released back to fr once it has been delivered and forwarded, so in
+// OwnershipBorrow mode deliver must not retain the data it is given.
+func (r *Relay) ServePeer(fr *enproto.Framer) error {
+	peer := r.hub.Add(fr.FrameWriter)
+	defer r.hub.Remove(peer)
+
+	for {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		err = r.serve(peer, msgType, payload)
+		fr.Release(payload)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// serve delivers one frame read from peer and forwards it onwards if it is relayed.
+// Forwarded frames are copied, so payload may be released once serve returns.
+func (r *Relay) serve(peer *enproto.HubConn, msgType byte, payload []byte) error {
+	if !r.types[msgType] {
+		return r.deliver.ServeFrame(msgType, payload)
+	}
+
+	if len(payload) < envelopeSize {
+		return ErrMalformed
+	}
+	var id ID
+	copy(id[:], payload)
+	hops := payload[len(id)]
+	data := payload[envelopeSize:]
+
+	if !r.seen.Add(id, time.Now()) {
+		return nil
+	}
+	if err := r.deliver.ServeFrame(msgType, data); err != nil {
+		return err
+	}
+	if hops > 0 {
+		others := func(c *enproto.HubConn) bool { return c != peer }
+		r.hub.BroadcastFunc(msgType, wrap(id, hops-1, data), others)
+	}
+	return nil
+}
+
+// wrap builds a relayed payload.
+func wrap(id ID, hops byte, data []byte) []byte {
+	payload := make([]byte, envelopeSize+len(data))
+	copy(payload, id[:])
+	payload[len(id)] = hops
+	copy(payload[envelopeSize:], data)
+	return payload
+}
@@ -0,0 +1,132 @@
+package relay
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ianchildress/enproto"
+)
+
+const typeGossip byte = 0x20
+
+// node is a Relay plus a record of what it delivered.
+type node struct {
+	*Relay
+
+	mu        sync.Mutex
+	delivered []string
+}
+
+func newNode(opts ...Option) *node {
+	n := &node{}
+	deliver := enproto.HandlerFunc(func(_ byte, data []byte) error {
+		n.mu.Lock()
+		n.delivered = append(n.delivered, string(data))
+		n.mu.Unlock()
+		return nil
+	})
+	n.Relay = New(enproto.NewHub(16, enproto.SlowConsumerDrop), deliver, opts...)
+	return n
+}
+
+func (n *node) deliveries() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.delivered...)
+}
+
+// link connects two nodes with an in-memory connection.
+func link(t *testing.T, a, b *node) {
+	ca, cb := net.Pipe()
+	t.Cleanup(func() { ca.Close(); cb.Close() })
+	go a.ServePeer(enproto.NewFramer(ca))
+	go b.ServePeer(enproto.NewFramer(cb))
+}
+
+// waitPeers waits until every node's hub has registered n peers.
+func waitPeers(n int, nodes ...*node) {
+	for _, nd := range nodes {
+		for nd.hub.Len() < n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// TestRelay_LoopSuppression verifies a message in a ring is delivered once per node.
+func TestRelay_LoopSuppression(t *testing.T) {
+	a, b, c := newNode(WithTypes(typeGossip)), newNode(WithTypes(typeGossip)), newNode(WithTypes(typeGossip))
+	link(t, a, b)
+	link(t, b, c)
+	link(t, c, a)
+	waitPeers(2, a, b, c)
+
+	if _, err := a.Originate(typeGossip, []byte("rumour")); err != nil {
+		t.Fatalf("Originate error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for (len(b.deliveries()) == 0 || len(c.deliveries()) == 0) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// Give any looping copies time to arrive.
+	time.Sleep(20 * time.Millisecond)
+
+	for name, n := range map[string]*node{"b": b, "c": c} {
+		if got := n.deliveries(); len(got) != 1 || got[0] != "rumour" {
+			t.Errorf("node %s delivered %q; want exactly [rumour]", name, got)
+		}
+	}
+	if got := a.deliveries(); len(got) != 0 {
+		t.Errorf("originator delivered its own message: %q", got)
+	}
+}
+
+// TestRelay_MaxHops verifies forwarding stops when the hop budget is spent.
+func TestRelay_MaxHops(t *testing.T) {
+	a := newNode(WithTypes(typeGossip), WithMaxHops(0))
+	b, c := newNode(WithTypes(typeGossip)), newNode(WithTypes(typeGossip))
+	link(t, a, b)
+	link(t, b, c)
+	waitPeers(1, a, c)
+	waitPeers(2, b)
+
+	if _, err := a.Originate(typeGossip, []byte("one hop")); err != nil {
+		t.Fatalf("Originate error: %v", err)
+	}
+
+	for len(b.deliveries()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if got := c.deliveries(); len(got) != 0 {
+		t.Errorf("node c delivered %q beyond the hop budget", got)
+	}
+}
+
+// TestRelay_ReleasesBorrowed verifies ServePeer hands borrowed payloads back once
+// they are delivered and forwarded.
+func TestRelay_ReleasesBorrowed(t *testing.T) {
+	var in bytes.Buffer
+	fw := enproto.NewFrameWriter(&in)
+	fw.WriteFrame(typeGossip, wrap(ID{1}, 1, []byte("relayed")))
+	fw.WriteFrame(0x01, []byte("local"))
+
+	budget := enproto.NewBudget(1<<20, nil)
+	fr := enproto.NewFramerRW(&in, io.Discard, enproto.WithPayloadOwnership(enproto.OwnershipBorrow), enproto.WithAllocator(budget))
+
+	n := newNode(WithTypes(typeGossip))
+	if err := n.ServePeer(fr); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.deliveries(); len(got) != 2 {
+		t.Errorf("delivered %q; want both frames", got)
+	}
+	if s := budget.Stats(); s.InUse != 0 {
+		t.Errorf("%d payload bytes still on loan", s.InUse)
+	}
+}
@@ -0,0 +1,143 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoHandler is wrapped by Dispatch errors for frames that match no route when
+// no default handler is set.
+var ErrNoHandler = errors.New("no handler for message type")
+
+// Handler processes a single frame.
+type Handler interface {
+	ServeFrame(msgType byte, payload []byte) error
+}
+
+// HandlerFunc adapts an ordinary function to the Handler interface.
+type HandlerFunc func(msgType byte, payload []byte) error
+
+// ServeFrame calls fn(msgType, payload).
+func (fn HandlerFunc) ServeFrame(msgType byte, payload []byte) error {
+	return fn(msgType, payload)
+}
+
+// Routes is a routing table from message types to handlers. Build one up and install
+// it with Router.Swap; it must not be modified once installed.
+type Routes struct {
+	handlers [256]Handler
+	fallback Handler
+}
+
+// Handle routes msgType to h, replacing any earlier route for it.
+func (t *Routes) Handle(msgType byte, h Handler) {
+	t.handlers[msgType] = h
+}
+
+// HandleRange routes every message type from lo to hi inclusive to h.
+func (t *Routes) HandleRange(lo, hi byte, h Handler) {
+	for i := int(lo); i <= int(hi); i++ {
+		t.handlers[i] = h
+	}
+}
+
+// HandleDefault sets the handler for message types without a route of their own.
+func (t *Routes) HandleDefault(h Handler) {
+	t.fallback = h
+}
+
+// Lookup returns the handler for msgType, falling back to the default handler.
+// It returns nil if neither is set.
+func (t *Routes) Lookup(msgType byte) Handler {
+	if h := t.handlers[msgType]; h != nil {
+		return h
+	}
+	return t.fallback
+}
+
+// Router dispatches frames to handlers by message type. Its routing table can be
+// changed or swapped wholesale while frames are being dispatched; each frame sees
+// either the old table or the new one, never a mix.
+//
+// The zero value is an empty Router ready to use.
+type Router struct {
+	mu     sync.Mutex // serialises writers of routes
+	routes atomic.Pointer[Routes]
+}
+
+// NewRouter returns an empty Router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// update applies fn to a copy of the current table and installs the result.
+func (rt *Router) update(fn func(*Routes)) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	next := &Routes{}
+	if cur := rt.routes.Load(); cur != nil {
+		*next = *cur
+	}
+	fn(next)
+	rt.routes.Store(next)
+}
+
+// Handle routes msgType to h.
+func (rt *Router) Handle(msgType byte, h Handler) {
+	rt.update(func(t *Routes) { t.Handle(msgType, h) })
+}
+
+// HandleRange routes every message type from lo to hi inclusive to h.
+func (rt *Router) HandleRange(lo, hi byte, h Handler) {
+	rt.update(func(t *Routes) { t.HandleRange(lo, hi, h) })
+}
+
+// HandleDefault sets the handler for message types without a route of their own.
+func (rt *Router) HandleDefault(h Handler) {
+	rt.update(func(t *Routes) { t.HandleDefault(h) })
+}
+
+// Swap atomically installs routes as the routing table and returns the previous one,
+// which is nil if none was set.
+func (rt *Router) Swap(routes *Routes) *Routes {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.routes.Swap(routes)
+}
+
+// Dispatch calls the handler routed for msgType and returns its error.
+func (rt *Router) Dispatch(msgType byte, payload []byte) error {
+	var h Handler
+	if t := rt.routes.Load(); t != nil {
+		h = t.Lookup(msgType)
+	}
+	if h == nil {
+		return fmt.Errorf("%w: %d", ErrNoHandler, msgType)
+	}
+	return h.ServeFrame(msgType, payload)
+}
+
+// Serve reads frames from r and dispatches each one in turn until reading or a
+// handler fails. A clean end of stream returns nil. Payloads are released back to r
+// once their handler returns, so in OwnershipBorrow mode handlers must not retain them.
+func (rt *Router) Serve(r *FrameReader) error {
+	for {
+		msgType, payload, err := r.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		err = rt.Dispatch(msgType, payload)
+		r.Release(payload)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestRouter_Dispatch verifies exact, range and default routes.
+func TestRouter_Dispatch(t *testing.T) {
+	var got []string
+	record := func(name string) Handler {
+		return HandlerFunc(func(msgType byte, payload []byte) error {
+			got = append(got, name)
+			return nil
+		})
+	}
+
+	rt := NewRouter()
+	rt.HandleRange(0x10, 0x1F, record("range"))
+	rt.Handle(0x12, record("exact"))
+
+	for _, msgType := range []byte{0x10, 0x12, 0x1F} {
+		if err := rt.Dispatch(msgType, nil); err != nil {
+			t.Fatalf("Dispatch(%#x) error: %v", msgType, err)
+		}
+	}
+	if err := rt.Dispatch(0x20, nil); !errors.Is(err, ErrNoHandler) {
+		t.Errorf("expected ErrNoHandler, got %v", err)
+	}
+
+	rt.HandleDefault(record("default"))
+	if err := rt.Dispatch(0x20, nil); err != nil {
+		t.Fatalf("Dispatch error: %v", err)
+	}
+
+	want := []string{"range", "exact", "range", "default"}
+	if len(got) != len(want) {
+		t.Fatalf("handled %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("handled %v; want %v", got, want)
+			break
+		}
+	}
+}
+
+// TestRouter_Swap verifies a whole routing table can be replaced at once.
+func TestRouter_Swap(t *testing.T) {
+	var rt Router
+
+	var old, next Routes
+	var hits int
+	old.Handle(0x1, HandlerFunc(func(byte, []byte) error { return errors.New("old table") }))
+	next.HandleDefault(HandlerFunc(func(byte, []byte) error { hits++; return nil }))
+
+	if prev := rt.Swap(&old); prev != nil {
+		t.Errorf("expected no previous table, got %v", prev)
+	}
+	if prev := rt.Swap(&next); prev != &old {
+		t.Errorf("expected previous table to be returned")
+	}
+
+	if err := rt.Dispatch(0x1, nil); err != nil {
+		t.Errorf("Dispatch error after swap: %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("default handler hits = %d; want 1", hits)
+	}
+}
+
+// TestRouter_Serve verifies frames are served until EOF and handler errors stop serving.
+func TestRouter_Serve(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fr := NewFramer(buf, WithPayloadOwnership(OwnershipBorrow))
+
+	for _, p := range []string{"a", "b", "stop", "never"} {
+		if err := fr.WriteFrame(0x1, []byte(p)); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+	}
+
+	errStop := errors.New("stop")
+	var served []string
+	rt := NewRouter()
+	rt.Handle(0x1, HandlerFunc(func(msgType byte, payload []byte) error {
+		if string(payload) == "stop" {
+			return errStop
+		}
+		served = append(served, string(payload))
+		return nil
+	}))
+
+	if err := rt.Serve(fr.FrameReader); !errors.Is(err, errStop) {
+		t.Errorf("expected handler error, got %v", err)
+	}
+	if len(served) != 2 || served[0] != "a" || served[1] != "b" {
+		t.Errorf("served %v; want [a b]", served)
+	}
+
+	// The remaining frame is served and the clean EOF returns nil.
+	if err := rt.Serve(fr.FrameReader); err != nil {
+		t.Errorf("expected nil at EOF, got %v", err)
+	}
+}
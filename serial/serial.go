@@ -0,0 +1,163 @@
+// Package serial implements a variant of the enproto framing hardened for serial and
+// RS-485 links, where bytes can be lost or garbled and the receiver may start
+// listening mid-stream.
+//
+// Frames use a compact layout with a 16-bit length and a mandatory CRC:
+//
+//	[2B Magic][1B Version][1B Type][2B Length][Payload][4B CRC-32C]
+//
+// all big-endian, with the CRC computed over version, type, length and payload. A
+// reader that meets a bad magic number, an implausible header or a CRC mismatch
+// discards a single byte and searches for the next magic number, so it recovers
+// from noise without losing the frames that follow it.
+package serial
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/ianchildress/enproto"
+)
+
+const (
+	headerSize  = 6
+	trailerSize = 4
+)
+
+// ErrFrameTooLarge is wrapped by write errors for payloads above the size limit.
+var ErrFrameTooLarge = errors.New("serial: frame too large")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Option configures a Framer.
+type Option func(*Framer)
+
+// WithMaxPayload sets the largest payload accepted. Smaller limits let a reader
+// reject noise that happens to look like a header sooner. The default is 1024.
+func WithMaxPayload(n uint16) Option {
+	return func(f *Framer) {
+		f.maxPayload = n
+	}
+}
+
+// Framer reads and writes serial frames.
+type Framer struct {
+	br *bufio.Reader
+	bw *bufio.Writer
+
+	maxPayload uint16
+	discarded  int64
+}
+
+// NewFramer wraps rw, typically an open serial port.
+func NewFramer(rw io.ReadWriter, opts ...Option) *Framer {
+	f := &Framer{maxPayload: 1024}
+	for _, opt := range opts {
+		opt(f)
+	}
+	// The reader must be able to peek a whole frame while checking its CRC.
+	f.br = bufio.NewReaderSize(rw, headerSize+int(f.maxPayload)+trailerSize)
+	f.bw = bufio.NewWriterSize(rw, headerSize+int(f.maxPayload)+trailerSize)
+	return f
+}
+
+// WriteFrame writes and flushes a frame.
+func (f *Framer) WriteFrame(msgType byte, payload []byte) error {
+	if len(payload) > int(f.maxPayload) || len(payload) > math.MaxUint16 {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, len(payload))
+	}
+
+	var header [headerSize]byte
+	binary.BigEndian.PutUint16(header[0:2], enproto.Magic)
+	header[2] = enproto.ProtocolVersion
+	header[3] = msgType
+	binary.BigEndian.PutUint16(header[4:6], uint16(len(payload)))
+
+	crc := crc32.Update(0, castagnoli, header[2:])
+	crc = crc32.Update(crc, castagnoli, payload)
+	var trailer [trailerSize]byte
+	binary.BigEndian.PutUint32(trailer[:], crc)
+
+	if _, err := f.bw.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := f.bw.Write(payload); err != nil {
+		return err
+	}
+	if _, err := f.bw.Write(trailer[:]); err != nil {
+		return err
+	}
+	return f.bw.Flush()
+}
+
+// ReadFrame returns the next frame that passes its CRC check, skipping any noise
+// before it. The payload is freshly allocated.
+//
+// At the end of the stream it returns io.EOF if nothing was pending, or
+// io.ErrUnexpectedEOF if it ended inside a frame or in noise. A header claiming more
+// bytes than remain is treated as noise, so the frames behind a false header are
+// still found.
+func (f *Framer) ReadFrame() (msgType byte, payload []byte, err error) {
+	for {
+		header, err := f.br.Peek(headerSize)
+		if err != nil {
+			return 0, nil, f.eof(err, len(header))
+		}
+
+		if binary.BigEndian.Uint16(header[0:2]) != enproto.Magic ||
+			header[2] != enproto.ProtocolVersion ||
+			binary.BigEndian.Uint16(header[4:6]) > f.maxPayload {
+			f.skip()
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(header[4:6]))
+		frame, err := f.br.Peek(headerSize + length + trailerSize)
+		if errors.Is(err, io.EOF) {
+			f.skip() // too short to be a frame; resync on what is left
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+
+		body := frame[2 : headerSize+length]
+		want := binary.BigEndian.Uint32(frame[headerSize+length:])
+		if crc32.Checksum(body, castagnoli) != want {
+			f.skip()
+			continue
+		}
+
+		msgType = frame[3]
+		payload = append([]byte(nil), frame[headerSize:headerSize+length]...)
+		f.br.Discard(len(frame))
+		return msgType, payload, nil
+	}
+}
+
+// skip drops one byte while searching for the next frame.
+func (f *Framer) skip() {
+	f.br.Discard(1)
+	f.discarded++
+}
+
+// eof maps an error from Peek, with n bytes still pending, to ReadFrame's result.
+func (f *Framer) eof(err error, n int) error {
+	if errors.Is(err, io.EOF) && n > 0 {
+		f.discarded += int64(n)
+		f.br.Discard(n)
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Discarded returns the number of bytes dropped as noise so far, a rough measure of
+// link quality.
+func (f *Framer) Discarded() int64 {
+	return f.discarded
+}
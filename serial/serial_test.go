@@ -0,0 +1,110 @@
+package serial
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestFramer_RoundTrip verifies frames round-trip on a clean link.
+func TestFramer_RoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewFramer(buf)
+
+	if err := f.WriteFrame(0x3, []byte("sensor reading")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	if got := buf.Len(); got != headerSize+len("sensor reading")+trailerSize {
+		t.Errorf("frame is %d bytes; want %d", got, headerSize+len("sensor reading")+trailerSize)
+	}
+
+	msgType, payload, err := f.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if msgType != 0x3 || string(payload) != "sensor reading" {
+		t.Errorf("frame = %d %q; want %d %q", msgType, payload, 0x3, "sensor reading")
+	}
+	if _, _, err := f.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+// TestFramer_Resync verifies the reader skips leading noise and corrupted frames.
+func TestFramer_Resync(t *testing.T) {
+	var wire bytes.Buffer
+	w := NewFramer(&wire)
+
+	wire.Write([]byte{0x00, 0x59, 0xFF, 0x59}) // noise, including a partial magic
+	if err := w.WriteFrame(0x1, []byte("first")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	corruptStart := wire.Len()
+	if err := w.WriteFrame(0x2, []byte("corrupted")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	wire.Bytes()[corruptStart+headerSize] ^= 0x01 // flip a payload bit
+	if err := w.WriteFrame(0x3, []byte("last")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	f := NewFramer(&wire)
+	for _, want := range []string{"first", "last"} {
+		_, payload, err := f.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame error: %v", err)
+		}
+		if string(payload) != want {
+			t.Errorf("payload = %q; want %q", payload, want)
+		}
+	}
+	if f.Discarded() == 0 {
+		t.Errorf("expected discarded bytes to be counted")
+	}
+}
+
+// TestFramer_MaxPayload ensures oversized payloads are refused on write.
+func TestFramer_MaxPayload(t *testing.T) {
+	f := NewFramer(&bytes.Buffer{}, WithMaxPayload(8))
+	if err := f.WriteFrame(0x1, make([]byte, 9)); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
+
+// TestFramer_Truncated ensures a frame cut off by the end of stream is reported.
+func TestFramer_Truncated(t *testing.T) {
+	var wire bytes.Buffer
+	if err := NewFramer(&wire).WriteFrame(0x1, []byte("cut short")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+	wire.Truncate(wire.Len() - 3)
+
+	if _, _, err := NewFramer(&wire).ReadFrame(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+// TestFramer_FalseHeaderAtEOF ensures a noise header claiming more bytes than the
+// stream has left does not hide the valid frame behind it.
+func TestFramer_FalseHeaderAtEOF(t *testing.T) {
+	var wire bytes.Buffer
+	wire.Write([]byte{0x59, 0x59, enproto.ProtocolVersion, 0x7F, 0x00, 0xC8}) // claims 200 bytes
+	if err := NewFramer(&wire).WriteFrame(0x1, []byte("kept")); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	f := NewFramer(&wire)
+	_, payload, err := f.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame error: %v", err)
+	}
+	if string(payload) != "kept" {
+		t.Errorf("payload = %q; want %q", payload, "kept")
+	}
+	if _, _, err := f.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF after the frame, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestSignVerify verifies signed frames arrive intact and unsigned ones pass through.
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fw := enproto.NewFrameWriter(&buf)
+	if err := NewWriter(fw, priv).WriteFrame(0x10, []byte("transfer 100")); err != nil {
+		t.Fatal(err)
+	}
+	fw.WriteFrame(0x20, []byte("chatter"))
+
+	var got []enproto.Frame
+	v := NewVerifier(enproto.HandlerFunc(func(msgType byte, payload []byte) error {
+		got = append(got, enproto.Frame{Type: msgType, Payload: append([]byte(nil), payload...)})
+		return nil
+	}), KeyVerifier(pub), RequireSigned(0x10))
+
+	fr := enproto.NewFrameReader(&buf)
+	for i := 0; i < 2; i++ {
+		msgType, payload, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.ServeFrame(msgType, payload); err != nil {
+			t.Fatalf("ServeFrame: %v", err)
+		}
+	}
+
+	if len(got) != 2 || got[0].Type != 0x10 || string(got[0].Payload) != "transfer 100" ||
+		got[1].Type != 0x20 || string(got[1].Payload) != "chatter" {
+		t.Errorf("delivered %+v", got)
+	}
+}
+
+// TestVerifier_Rejects verifies tampered, foreign-key, malformed and required-but-
+// unsigned frames are refused.
+func TestVerifier_Rejects(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	_, other, _ := ed25519.GenerateKey(nil)
+
+	signed := func(key ed25519.PrivateKey, msgType byte, payload string) []byte {
+		var buf bytes.Buffer
+		NewWriter(enproto.NewFrameWriter(&buf), key).WriteFrame(msgType, []byte(payload))
+		_, p, err := enproto.NewFrameReader(&buf).ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tampered := signed(priv, 0x10, "transfer 100")
+	tampered[len(tampered)-1] = '9'
+	retyped := signed(priv, 0x10, "transfer 100")
+	retyped[0] = 0x11
+
+	tests := []struct {
+		name    string
+		msgType byte
+		payload []byte
+		want    error
+	}{
+		{"tampered payload", TypeSigned, tampered, ErrBadSignature},
+		{"changed type", TypeSigned, retyped, ErrBadSignature},
+		{"other key", TypeSigned, signed(other, 0x10, "transfer 100"), ErrBadSignature},
+		{"short", TypeSigned, []byte{0x10, 1, 2}, ErrMalformed},
+		{"unsigned", 0x10, []byte("transfer 100"), ErrUnsigned},
+	}
+	v := NewVerifier(enproto.HandlerFunc(func(byte, []byte) error {
+		t.Error("rejected frame was delivered")
+		return nil
+	}), KeyVerifier(pub), RequireSigned(0x10))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := v.ServeFrame(tt.msgType, tt.payload); !errors.Is(err, tt.want) {
+				t.Errorf("got %v; want %v", err, tt.want)
+			}
+		})
+	}
+}
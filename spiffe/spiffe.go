@@ -0,0 +1,180 @@
+// Package spiffe identifies enproto peers by their SPIFFE workload identity when
+// connections run over mutual TLS.
+//
+// An X.509 SVID is a certificate carrying exactly one URI SAN of the form
+// spiffe://trust-domain/path. VerifyPeer plugs into tls.Config to check that the
+// verified peer certificate is an SVID and to let the caller authorize its ID, for
+// example by trust domain; FromConn reads the ID back after the handshake so that
+// handlers for the connection can be built with it.
+//
+// VerifyPeer leaves chain verification to crypto/tls, through ClientCAs or RootCAs,
+// and then trusts the trust domain named in the SVID. That is only sound when the
+// pool holds the CAs of a single trust domain: with the bundles of several federated
+// domains in one pool, any of their CAs could issue an SVID naming another domain.
+// For federation, VerifyBundles checks each SVID against the bundle of the trust
+// domain it names instead.
+package spiffe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrNotSVID is wrapped by errors for certificates or URIs that are not valid
+	// SPIFFE identities.
+	ErrNotSVID = errors.New("spiffe: not an SVID")
+
+	// ErrUnverified is returned when TLS has not verified the peer's certificate
+	// chain, so there is no identity to trust.
+	ErrUnverified = errors.New("spiffe: peer certificate not verified")
+
+	// ErrUntrustedDomain is wrapped by AllowTrustDomains errors.
+	ErrUntrustedDomain = errors.New("spiffe: trust domain not allowed")
+)
+
+// ID is a SPIFFE ID.
+type ID struct {
+	TrustDomain string
+	Path        string // empty or starting with "/"
+}
+
+// String returns the ID in its spiffe:// form.
+func (id ID) String() string {
+	return "spiffe://" + id.TrustDomain + id.Path
+}
+
+// ParseID parses a spiffe:// URI.
+func ParseID(s string) (ID, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ID{}, fmt.Errorf("%w: %v", ErrNotSVID, err)
+	}
+	return idFromURL(u)
+}
+
+func idFromURL(u *url.URL) (ID, error) {
+	switch {
+	case u.Scheme != "spiffe":
+		return ID{}, fmt.Errorf("%w: scheme %q", ErrNotSVID, u.Scheme)
+	case u.Host == "" || u.Host != strings.ToLower(u.Host) || u.Port() != "":
+		return ID{}, fmt.Errorf("%w: trust domain %q", ErrNotSVID, u.Host)
+	case u.User != nil || u.RawQuery != "" || u.Fragment != "" || u.Opaque != "":
+		return ID{}, fmt.Errorf("%w: %q has extra URI parts", ErrNotSVID, u)
+	case strings.HasSuffix(u.Path, "/"):
+		return ID{}, fmt.Errorf("%w: path %q ends in /", ErrNotSVID, u.Path)
+	}
+	return ID{TrustDomain: u.Host, Path: u.Path}, nil
+}
+
+// FromCertificate returns the SPIFFE ID of an X.509 SVID. It checks the certificate's
+// form only, not its chain.
+func FromCertificate(cert *x509.Certificate) (ID, error) {
+	if cert.IsCA {
+		return ID{}, fmt.Errorf("%w: CA certificate", ErrNotSVID)
+	}
+	if len(cert.URIs) != 1 {
+		return ID{}, fmt.Errorf("%w: %d URI SANs", ErrNotSVID, len(cert.URIs))
+	}
+	return idFromURL(cert.URIs[0])
+}
+
+// FromConn returns the SPIFFE ID of the peer on a TLS connection whose handshake has
+// completed and verified the peer's chain.
+func FromConn(conn *tls.Conn) (ID, error) {
+	return fromChains(conn.ConnectionState().VerifiedChains)
+}
+
+func fromChains(chains [][]*x509.Certificate) (ID, error) {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return ID{}, ErrUnverified
+	}
+	return FromCertificate(chains[0][0])
+}
+
+// Authorizer decides whether a peer with the given ID may connect.
+type Authorizer func(ID) error
+
+// AllowTrustDomains returns an Authorizer admitting IDs from the given trust domains.
+func AllowTrustDomains(domains ...string) Authorizer {
+	return func(id ID) error {
+		for _, d := range domains {
+			if id.TrustDomain == d {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrUntrustedDomain, id)
+	}
+}
+
+// VerifyPeer returns a function for tls.Config.VerifyPeerCertificate that fails the
+// handshake unless the verified peer certificate is an SVID whose ID authorize
+// accepts. The config must also verify chains, with ClientAuth set to
+// tls.RequireAndVerifyClientCert on servers.
+//
+// ClientCAs or RootCAs must hold the bundle of one trust domain only, since the chain
+// is not tied to the domain the SVID names. Use VerifyBundles to accept several.
+func VerifyPeer(authorize Authorizer) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+		id, err := fromChains(verifiedChains)
+		if err != nil {
+			return err
+		}
+		return authorize(id)
+	}
+}
+
+// Bundles maps trust domains to the CA certificates that issue their SVIDs.
+type Bundles map[string]*x509.CertPool
+
+// VerifyBundles returns a function for tls.Config.VerifyPeerCertificate that verifies
+// the peer's SVID chain against the bundle of the trust domain the SVID names, then
+// lets authorize decide on its ID. SVIDs from domains without a bundle fail with
+// ErrUntrustedDomain, so one domain's CA cannot vouch for another's workloads.
+//
+// It does the chain verification itself, so crypto/tls must not: servers set
+// ClientAuth to tls.RequireAnyClientCert and leave ClientCAs empty, and clients set
+// InsecureSkipVerify. The connection then has no VerifiedChains, so read the peer's
+// ID with FromCertificate(conn.ConnectionState().PeerCertificates[0]) instead of
+// FromConn.
+func VerifyBundles(bundles Bundles, authorize Authorizer) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return ErrUnverified
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("spiffe: peer certificate %d: %w", i, err)
+			}
+			certs[i] = cert
+		}
+
+		id, err := FromCertificate(certs[0])
+		if err != nil {
+			return err
+		}
+		roots, ok := bundles[id.TrustDomain]
+		if !ok {
+			return fmt.Errorf("%w: no bundle for %s", ErrUntrustedDomain, id)
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err = certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return fmt.Errorf("spiffe: %s: %w", id, err)
+		}
+		return authorize(id)
+	}
+}
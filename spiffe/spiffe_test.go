@@ -0,0 +1,205 @@
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestParseID covers valid IDs and the malformed forms the SPIFFE spec forbids.
+func TestParseID(t *testing.T) {
+	id, err := ParseID("spiffe://example.org/ns/prod/sa/billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.TrustDomain != "example.org" || id.Path != "/ns/prod/sa/billing" {
+		t.Errorf("parsed %+v", id)
+	}
+	if got := id.String(); got != "spiffe://example.org/ns/prod/sa/billing" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, s := range []string{
+		"https://example.org/a",
+		"spiffe:///a",
+		"spiffe://Example.org/a",
+		"spiffe://example.org:8080/a",
+		"spiffe://example.org/a/",
+		"spiffe://example.org/a?q=1",
+		"spiffe://user@example.org/a",
+	} {
+		if _, err := ParseID(s); !errors.Is(err, ErrNotSVID) {
+			t.Errorf("ParseID(%q) = %v; want ErrNotSVID", s, err)
+		}
+	}
+}
+
+// testPKI is a CA that issues leaf certificates for the tests.
+type testPKI struct {
+	pool  *x509.CertPool
+	ca    *x509.Certificate
+	key   *ecdsa.PrivateKey
+	nextN int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &testPKI{pool: pool, ca: ca, key: key, nextN: 2}
+}
+
+// issue returns a TLS certificate for the given URI SANs.
+func (p *testPKI) issue(t *testing.T, uris ...string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(p.nextN),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"server"},
+	}
+	p.nextN++
+	for _, s := range uris {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake runs a mutual TLS handshake in which the server authorizes the client.
+func handshake(t *testing.T, p *testPKI, client tls.Certificate, authorize Authorizer) (*tls.Conn, error) {
+	t.Helper()
+	return handshakeWith(t, p, client, &tls.Config{
+		ClientCAs:             p.pool,
+		ClientAuth:            tls.RequireAndVerifyClientCert,
+		VerifyPeerCertificate: VerifyPeer(authorize),
+	})
+}
+
+// handshakeWith runs a mutual TLS handshake with a server certificate from p and the
+// rest of the server's config from server.
+func handshakeWith(t *testing.T, p *testPKI, client tls.Certificate, server *tls.Config) (*tls.Conn, error) {
+	t.Helper()
+	// A socket rather than net.Pipe, so both ends can write at once, as they do when
+	// the server rejects the client's certificate.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	cc, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close(); sc.Close() })
+
+	server.Certificates = []tls.Certificate{p.issue(t, "spiffe://example.org/server")}
+	conn := tls.Server(sc, server)
+	go tls.Client(cc, &tls.Config{
+		Certificates: []tls.Certificate{client},
+		RootCAs:      p.pool,
+		ServerName:   "server",
+	}).Handshake()
+	return conn, conn.Handshake()
+}
+
+// TestVerifyPeer verifies authorized SVIDs connect and expose their ID, while other
+// trust domains and non-SVID certificates are refused during the handshake.
+func TestVerifyPeer(t *testing.T) {
+	p := newTestPKI(t)
+	allow := AllowTrustDomains("example.org")
+
+	conn, err := handshake(t, p, p.issue(t, "spiffe://example.org/billing"), allow)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	id, err := FromConn(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != (ID{TrustDomain: "example.org", Path: "/billing"}) {
+		t.Errorf("FromConn = %+v", id)
+	}
+
+	if _, err := handshake(t, p, p.issue(t, "spiffe://evil.example/billing"), allow); !errors.Is(err, ErrUntrustedDomain) {
+		t.Errorf("foreign trust domain: got %v; want ErrUntrustedDomain", err)
+	}
+	if _, err := handshake(t, p, p.issue(t), allow); !errors.Is(err, ErrNotSVID) {
+		t.Errorf("no URI SAN: got %v; want ErrNotSVID", err)
+	}
+	two := p.issue(t, "spiffe://example.org/a", "spiffe://example.org/b")
+	if _, err := handshake(t, p, two, allow); !errors.Is(err, ErrNotSVID) {
+		t.Errorf("two URI SANs: got %v; want ErrNotSVID", err)
+	}
+}
+
+// TestVerifyBundles verifies SVIDs are checked against the bundle of the trust domain
+// they name, so a federated domain's CA cannot issue SVIDs for another domain.
+func TestVerifyBundles(t *testing.T) {
+	a, b := newTestPKI(t), newTestPKI(t)
+	verify := VerifyBundles(Bundles{"example.org": a.pool, "partner.example": b.pool},
+		AllowTrustDomains("example.org", "partner.example"))
+	server := func() *tls.Config {
+		return &tls.Config{ClientAuth: tls.RequireAnyClientCert, VerifyPeerCertificate: verify}
+	}
+
+	conn, err := handshakeWith(t, a, b.issue(t, "spiffe://partner.example/api"), server())
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	id, err := FromCertificate(conn.ConnectionState().PeerCertificates[0])
+	if err != nil || id.TrustDomain != "partner.example" {
+		t.Errorf("peer ID = %+v, %v; want partner.example", id, err)
+	}
+
+	if _, err := handshakeWith(t, a, b.issue(t, "spiffe://example.org/billing"), server()); err == nil {
+		t.Error("partner CA issued an accepted SVID for example.org")
+	}
+	if _, err := handshakeWith(t, a, a.issue(t, "spiffe://other.example/x"), server()); !errors.Is(err, ErrUntrustedDomain) {
+		t.Errorf("domain without a bundle: got %v; want ErrUntrustedDomain", err)
+	}
+}
@@ -0,0 +1,94 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// WithSpill makes ReadFrameAt write payloads longer than threshold bytes to a
+// temporary file in dir instead of holding them in memory, so very large frames do not
+// have to fit on the heap. An empty dir means os.TempDir. Without this option
+// ReadFrameAt keeps every payload in memory. ReadFrame and friends are unaffected.
+func WithSpill(threshold uint32, dir string) Option {
+	return func(c *config) {
+		c.spillThreshold = threshold
+		c.spillDir = dir
+	}
+}
+
+// SpilledPayload is a payload returned by ReadFrameAt, held either in memory or in a
+// temporary file. Close it when done to release the file.
+type SpilledPayload struct {
+	size int64
+	mem  *bytes.Reader
+	file *os.File
+}
+
+// ReadAt implements io.ReaderAt over the payload.
+func (p *SpilledPayload) ReadAt(b []byte, off int64) (int, error) {
+	if p.file != nil {
+		return p.file.ReadAt(b, off)
+	}
+	return p.mem.ReadAt(b, off)
+}
+
+// Size returns the payload length in bytes.
+func (p *SpilledPayload) Size() int64 {
+	return p.size
+}
+
+// Spilled reports whether the payload was written to a temporary file.
+func (p *SpilledPayload) Spilled() bool {
+	return p.file != nil
+}
+
+// Close removes the temporary file backing a spilled payload. It does nothing for
+// payloads held in memory. The payload must not be read after Close.
+func (p *SpilledPayload) Close() error {
+	if p.file == nil {
+		return nil
+	}
+	f := p.file
+	p.file = nil
+	p.mem = bytes.NewReader(nil)
+	return errors.Join(f.Close(), os.Remove(f.Name()))
+}
+
+// ReadFrameAt reads the next frame like ReadFrame, but returns its payload as an
+// io.ReaderAt. With WithSpill, payloads above the threshold are streamed to a
+// temporary file rather than allocated, which keeps a 100 MiB frame from costing
+// 100 MiB of heap.
+func (r *FrameReader) ReadFrameAt() (msgType byte, payload *SpilledPayload, err error) {
+	msgType, length, err := r.readHeader()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if r.cfg.spillThreshold == 0 || length <= r.cfg.spillThreshold {
+		buf := make([]byte, length)
+		if err := r.readPayload(buf); err != nil {
+			return 0, nil, err
+		}
+		return msgType, &SpilledPayload{size: int64(length), mem: bytes.NewReader(buf)}, nil
+	}
+
+	f, err := os.CreateTemp(r.cfg.spillDir, "enproto-spill-*")
+	if err != nil {
+		return 0, nil, err
+	}
+	p := &SpilledPayload{size: int64(length), file: f}
+	err = r.budgeted(int(length), func() error {
+		_, err := io.CopyN(f, r.br, int64(length))
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	})
+	if err != nil {
+		p.Close()
+		return 0, nil, err
+	}
+	return msgType, p, nil
+}
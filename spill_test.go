@@ -0,0 +1,71 @@
+package enproto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// TestReadFrameAt_Spill verifies large payloads land in a temp file that Close removes,
+// while small ones stay in memory.
+func TestReadFrameAt_Spill(t *testing.T) {
+	dir := t.TempDir()
+	big := bytes.Repeat([]byte("x"), 4096)
+
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	fw.WriteFrame(0x01, []byte("small"))
+	fw.WriteFrame(0x02, big)
+
+	fr := NewFrameReader(&buf, WithSpill(1024, dir))
+
+	msgType, p, err := fr.ReadFrameAt()
+	if err != nil {
+		t.Fatalf("ReadFrameAt: %v", err)
+	}
+	if msgType != 0x01 || p.Spilled() || p.Size() != 5 {
+		t.Errorf("small frame: type %d spilled %v size %d; want 1, false, 5", msgType, p.Spilled(), p.Size())
+	}
+	got, _ := io.ReadAll(io.NewSectionReader(p, 0, p.Size()))
+	if string(got) != "small" {
+		t.Errorf("small payload = %q; want %q", got, "small")
+	}
+
+	msgType, p, err = fr.ReadFrameAt()
+	if err != nil {
+		t.Fatalf("ReadFrameAt: %v", err)
+	}
+	if msgType != 0x02 || !p.Spilled() || p.Size() != int64(len(big)) {
+		t.Errorf("big frame: type %d spilled %v size %d; want 2, true, %d", msgType, p.Spilled(), p.Size(), len(big))
+	}
+	got, _ = io.ReadAll(io.NewSectionReader(p, 0, p.Size()))
+	if !bytes.Equal(got, big) {
+		t.Errorf("spilled payload differs from what was written")
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d files left in spill dir after Close; want 0", len(entries))
+	}
+}
+
+// TestReadFrameAt_Truncated ensures a short spilled payload fails and leaves no file.
+func TestReadFrameAt_Truncated(t *testing.T) {
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	NewFrameWriter(&buf).WriteFrame(0x01, make([]byte, 2048))
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-10])
+
+	fr := NewFrameReader(truncated, WithSpill(1024, dir))
+	if _, _, err := fr.ReadFrameAt(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadFrameAt error = %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d files left in spill dir after failed read; want 0", len(entries))
+	}
+}
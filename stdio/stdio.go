@@ -0,0 +1,68 @@
+// Package stdio frames enproto over a child process's standard input and output,
+// so plugins can speak the protocol without opening sockets.
+//
+// The parent starts the plugin with Start; the plugin wraps its own standard streams
+// with Child. Standard error stays free for the plugin's logs.
+package stdio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Conn is a Framer connected to a running child process.
+type Conn struct {
+	*enproto.Framer
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// Start starts cmd with its stdin and stdout connected to a Framer configured by opts.
+// cmd.Stdin and cmd.Stdout must not be set.
+func Start(cmd *exec.Cmd, opts ...enproto.Option) (*Conn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &Conn{
+		Framer: enproto.NewFramerRW(stdout, stdin, opts...),
+		cmd:    cmd,
+		stdin:  stdin,
+	}, nil
+}
+
+// Close flushes buffered frames, closes the child's stdin so it reads a clean end of
+// stream, and waits for the child to exit. Finish reading any frames you need before
+// calling Close: the child's stdout is closed once it exits. The returned error
+// reports a failed flush or the child's exit status.
+func (c *Conn) Close() error {
+	flushErr := c.Flush()
+	closeErr := c.stdin.Close()
+	waitErr := c.cmd.Wait()
+	return errors.Join(flushErr, closeErr, waitErr)
+}
+
+// Process returns the running child process.
+func (c *Conn) Process() *os.Process {
+	return c.cmd.Process
+}
+
+// Child returns a Framer over the current process's stdin and stdout, for use by a
+// process started with Start. Nothing else may read stdin or write stdout.
+func Child(opts ...enproto.Option) *enproto.Framer {
+	return enproto.NewFramerRW(os.Stdin, os.Stdout, opts...)
+}
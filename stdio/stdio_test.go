@@ -0,0 +1,80 @@
+package stdio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestHelperProcess is not a real test: it is the child process started by the
+// tests below, echoing frames until stdin ends.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("ENPROTO_STDIO_HELPER") != "1" {
+		return
+	}
+
+	fr := Child()
+	for {
+		msgType, payload, err := fr.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			os.Exit(0)
+		}
+		if err != nil {
+			os.Exit(3)
+		}
+		if err := fr.WriteFrame(msgType, payload); err != nil {
+			os.Exit(4)
+		}
+	}
+}
+
+func helperCommand() *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "ENPROTO_STDIO_HELPER=1")
+	return cmd
+}
+
+// TestStart verifies frames round-trip through a child process and Close reaps it.
+func TestStart(t *testing.T) {
+	conn, err := Start(helperCommand())
+	if err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+
+	for _, p := range []string{"ping", "pong"} {
+		if err := conn.WriteFrame(0x1, []byte(p)); err != nil {
+			t.Fatalf("WriteFrame error: %v", err)
+		}
+		_, payload, err := conn.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame error: %v", err)
+		}
+		if string(payload) != p {
+			t.Errorf("payload = %q; want %q", payload, p)
+		}
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+}
+
+// TestStart_ChildFailure ensures a child's failing exit status is reported by Close.
+func TestStart_ChildFailure(t *testing.T) {
+	conn, err := Start(helperCommand())
+	if err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+
+	// A bad header makes the child exit with status 3.
+	if _, err := conn.stdin.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+
+	var exitErr *exec.ExitError
+	if err := conn.Close(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+}
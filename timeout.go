@@ -0,0 +1,57 @@
+package enproto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrFrameTimeout is wrapped by read errors for frames whose payload did not arrive
+// within the WithFrameReadTimeout budget.
+var ErrFrameTimeout = errors.New("frame read timed out")
+
+// readDeadliner is implemented by transports such as net.Conn and *os.File.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// WithFrameReadTimeout limits how long a frame's payload may take to arrive once its
+// header has been read, so a peer that trickles bytes cannot pin a reader forever.
+// Overruns fail the read with ErrFrameTimeout; the stream is then mid-frame and the
+// connection should be closed.
+//
+// The budget relies on the transport's SetReadDeadline and has no effect on readers
+// without one. The deadline is cleared after each payload, so it replaces any read
+// deadline the caller set while a payload is being read. The default is no limit.
+func WithFrameReadTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.frameReadTimeout = d
+	}
+}
+
+// budgeted runs read, which consumes the current frame's n-byte payload, under the
+// frame read timeout if one applies. Payloads already buffered need no deadline.
+func (r *FrameReader) budgeted(n int, read func() error) error {
+	if r.cfg.frameReadTimeout <= 0 || r.dl == nil || r.br.Buffered() >= n {
+		return read()
+	}
+
+	r.dl.SetReadDeadline(time.Now().Add(r.cfg.frameReadTimeout))
+	err := read()
+	r.dl.SetReadDeadline(time.Time{})
+
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: payload incomplete after %v", ErrFrameTimeout, r.cfg.frameReadTimeout)
+	}
+	return err
+}
+
+// readPayload fills payload from the stream under the frame read timeout.
+func (r *FrameReader) readPayload(payload []byte) error {
+	return r.budgeted(len(payload), func() error {
+		_, err := io.ReadFull(r.br, payload)
+		return err
+	})
+}
@@ -0,0 +1,57 @@
+package enproto
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestWithFrameReadTimeout verifies a trickled payload fails with ErrFrameTimeout
+// while a prompt one is read normally.
+func TestWithFrameReadTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	fr := NewFrameReader(server, WithFrameReadTimeout(50*time.Millisecond))
+
+	go func() {
+		fw := NewFrameWriter(client)
+		fw.WriteFrame(0x01, []byte("prompt"))
+		// Then a header promising 10 bytes, followed by only a few of them.
+		client.Write(EncodeHeader(Header{Magic: Magic, Version: ProtocolVersion, Type: 0x02, Length: 10}))
+		client.Write([]byte("abc"))
+	}()
+
+	if _, p, err := fr.ReadFrame(); err != nil || string(p) != "prompt" {
+		t.Fatalf("first frame = %q, %v; want %q", p, err, "prompt")
+	}
+
+	start := time.Now()
+	_, _, err := fr.ReadFrame()
+	if !errors.Is(err, ErrFrameTimeout) {
+		t.Fatalf("ReadFrame error = %v; want %v", err, ErrFrameTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timed out after %v; want about 50ms", elapsed)
+	}
+}
+
+// TestWithFrameReadTimeout_IdleHeader ensures the budget does not limit the wait for
+// a header.
+func TestWithFrameReadTimeout_IdleHeader(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	fr := NewFrameReader(server, WithFrameReadTimeout(10*time.Millisecond))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		NewFrameWriter(client).WriteFrame(0x01, []byte("late"))
+	}()
+
+	if _, p, err := fr.ReadFrame(); err != nil || string(p) != "late" {
+		t.Errorf("ReadFrame = %q, %v; want %q", p, err, "late")
+	}
+}
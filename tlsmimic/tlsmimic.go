@@ -0,0 +1,103 @@
+// Package tlsmimic shapes a byte stream to look like TLS 1.3 application data on the
+// wire, for networks that block unknown binary protocols.
+//
+// Written bytes are carried in records of [1B content type 0x17][2B legacy version
+// 0x0303][2B length][data], at most MaxRecordData bytes each, as TLS 1.3 sends
+// encrypted application data. The records carry the data as is, so wrap the result
+// with package obfs to make their contents look random as well:
+//
+//	fr := enproto.NewFramer(obfs.Wrap(tlsmimic.Wrap(conn), psk))
+//
+// Only the record layer is imitated; there is no handshake, so a middlebox that
+// checks for one will still tell the traffic apart.
+package tlsmimic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	recordHeaderSize    = 5
+	typeApplicationData = 0x17
+	legacyVersion       = 0x0303
+
+	// MaxRecordData is the most data a record carries, TLS's plaintext limit.
+	MaxRecordData = 1 << 14
+)
+
+// ErrBadRecord is wrapped by Read errors for input that is not an application data
+// record.
+var ErrBadRecord = errors.New("tlsmimic: not a TLS application data record")
+
+// Conn carries a stream in TLS-style records over a transport. Reads and writes may
+// run concurrently with each other.
+type Conn struct {
+	rw io.ReadWriter
+
+	rmu  sync.Mutex
+	hdr  [recordHeaderSize]byte
+	left int // data bytes left in the current record
+
+	wmu  sync.Mutex
+	wbuf []byte
+}
+
+// Wrap returns a Conn over rw.
+func Wrap(rw io.ReadWriter) *Conn {
+	return &Conn{rw: rw}
+}
+
+// Read returns data from the records read from the transport.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	for c.left == 0 {
+		if _, err := io.ReadFull(c.rw, c.hdr[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = fmt.Errorf("%w: truncated header", ErrBadRecord)
+			}
+			return 0, err
+		}
+		n := int(binary.BigEndian.Uint16(c.hdr[3:]))
+		if c.hdr[0] != typeApplicationData || binary.BigEndian.Uint16(c.hdr[1:]) != legacyVersion || n > MaxRecordData {
+			return 0, fmt.Errorf("%w: header %x", ErrBadRecord, c.hdr)
+		}
+		c.left = n // empty records are skipped
+	}
+
+	n, err := c.rw.Read(p[:min(len(p), c.left)])
+	c.left -= n
+	if err == io.EOF && c.left > 0 {
+		err = fmt.Errorf("%w: truncated record", ErrBadRecord)
+	}
+	return n, err
+}
+
+// Write writes p in as many records as it needs, with a single write to the transport.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.wbuf = c.wbuf[:0]
+	for rest := p; len(rest) > 0; {
+		n := min(len(rest), MaxRecordData)
+		c.wbuf = append(c.wbuf, typeApplicationData)
+		c.wbuf = binary.BigEndian.AppendUint16(c.wbuf, legacyVersion)
+		c.wbuf = binary.BigEndian.AppendUint16(c.wbuf, uint16(n))
+		c.wbuf = append(c.wbuf, rest[:n]...)
+		rest = rest[n:]
+	}
+
+	n, err := c.rw.Write(c.wbuf)
+	if n < len(c.wbuf) {
+		// Report the data bytes of the records that got out whole.
+		whole := n / (recordHeaderSize + MaxRecordData)
+		return whole * MaxRecordData, err
+	}
+	return len(p), err
+}
@@ -0,0 +1,73 @@
+package tlsmimic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// rw is an in-memory transport.
+type rw struct{ bytes.Buffer }
+
+// TestRoundTrip verifies frames survive record framing and the wire is a well-formed
+// sequence of application data records.
+func TestRoundTrip(t *testing.T) {
+	big := make([]byte, 3*MaxRecordData+7)
+	rand.New(rand.NewSource(1)).Read(big)
+
+	var wire rw
+	w := enproto.NewFrameWriter(Wrap(&wire))
+	w.WriteFrame(0x01, []byte("hello"))
+	w.WriteFrame(0x02, big)
+
+	// Walk the records.
+	raw := wire.Bytes()
+	records := 0
+	for off := 0; off < len(raw); records++ {
+		if raw[off] != 0x17 || binary.BigEndian.Uint16(raw[off+1:]) != 0x0303 {
+			t.Fatalf("record %d header %x", records, raw[off:off+5])
+		}
+		n := int(binary.BigEndian.Uint16(raw[off+3:]))
+		if n > MaxRecordData {
+			t.Fatalf("record %d carries %d bytes", records, n)
+		}
+		off += recordHeaderSize + n
+	}
+	if records < 5 {
+		t.Errorf("%d records; want at least 5", records)
+	}
+
+	r := enproto.NewFrameReader(Wrap(&wire))
+	if _, p, err := r.ReadFrame(); err != nil || string(p) != "hello" {
+		t.Fatalf("first frame %q, %v", p, err)
+	}
+	if _, p, err := r.ReadFrame(); err != nil || !bytes.Equal(p, big) {
+		t.Fatalf("second frame: %d bytes, %v", len(p), err)
+	}
+	if _, _, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("after last frame: %v; want EOF", err)
+	}
+}
+
+// TestBadRecords verifies other record types and truncated records are rejected.
+func TestBadRecords(t *testing.T) {
+	for name, wire := range map[string][]byte{
+		"handshake record": {0x16, 0x03, 0x03, 0x00, 0x01, 'x'},
+		"oversized":        {0x17, 0x03, 0x03, 0x40, 0x01},
+		"truncated header": {0x17, 0x03},
+		"truncated data":   {0x17, 0x03, 0x03, 0x00, 0x05, 'a', 'b'},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := Wrap(&rw{*bytes.NewBuffer(wire)})
+			_, err := io.ReadAll(c)
+			if !errors.Is(err, ErrBadRecord) {
+				t.Errorf("got %v; want ErrBadRecord", err)
+			}
+		})
+	}
+}
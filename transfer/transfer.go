@@ -0,0 +1,277 @@
+// Package transfer sends messages too large for one frame as a sequence of chunk
+// frames, with progress reporting and cancellation on both ends.
+//
+// A transfer is a START frame, [16B transfer ID][1B message type][8B total size],
+// followed by CHUNK frames, [16B ID][8B offset][data], and an END frame, [16B ID]. A
+// sender that gives up mid-transfer sends CANCEL, [16B ID], instead of END.
+//
+// A transfer cut off by a lost connection can be resumed on a new one: the receiver
+// sends RESUME, [16B ID][8B offset], naming the first byte it is missing, and the
+// sender continues with CHUNK frames from that offset and END. Integers are
+// big-endian. The control frame types are reserved on connections that use transfers.
+package transfer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ianchildress/enproto"
+)
+
+// Control frame types.
+const (
+	TypeStart  byte = 0xD0
+	TypeChunk  byte = 0xD1
+	TypeEnd    byte = 0xD2
+	TypeCancel byte = 0xD3
+	TypeResume byte = 0xD4
+)
+
+// ChunkSize is the largest amount of message data carried by one CHUNK frame.
+const ChunkSize = 64 * 1024
+
+var (
+	ErrMalformed = errors.New("transfer: malformed control frame")
+
+	// ErrCanceled is returned by ReceiveLargeMessage when the sender cancels.
+	ErrCanceled = errors.New("transfer: canceled by sender")
+
+	// ErrUnexpectedFrame is returned for frames that do not belong to the transfer.
+	ErrUnexpectedFrame = errors.New("transfer: unexpected frame")
+
+	// ErrSizeMismatch is wrapped by errors for transfers whose data does not add up
+	// to the announced size.
+	ErrSizeMismatch = errors.New("transfer: size mismatch")
+)
+
+// ID identifies a transfer.
+type ID [16]byte
+
+const idSize = len(ID{})
+
+// NewID returns a random transfer ID.
+func NewID() (ID, error) {
+	var id ID
+	_, err := rand.Read(id[:])
+	return id, err
+}
+
+// SendLargeMessage sends size bytes read from r as one message of type msgType,
+// calling onProgress, if not nil, after every chunk with the bytes sent so far. If
+// ctx is done or r fails or ends early, the transfer is canceled on the wire and the
+// error returned.
+func SendLargeMessage(ctx context.Context, w *enproto.FrameWriter, msgType byte, r io.Reader, size int64, onProgress func(sent, total int64)) error {
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	return send(ctx, w, id, msgType, r, size, onProgress)
+}
+
+// SendResumable is like SendLargeMessage with a caller-chosen ID, from NewID, and a
+// source that can be reread, so that ResumeSend can continue the transfer if the
+// connection is lost.
+func SendResumable(ctx context.Context, w *enproto.FrameWriter, id ID, msgType byte, r io.ReaderAt, size int64, onProgress func(sent, total int64)) error {
+	return send(ctx, w, id, msgType, io.NewSectionReader(r, 0, size), size, onProgress)
+}
+
+func send(ctx context.Context, w *enproto.FrameWriter, id ID, msgType byte, r io.Reader, size int64, onProgress func(sent, total int64)) error {
+	if size < 0 {
+		return fmt.Errorf("%w: negative size %d", ErrSizeMismatch, size)
+	}
+
+	start := make([]byte, 0, idSize+1+8)
+	start = append(start, id[:]...)
+	start = append(start, msgType)
+	start = binary.BigEndian.AppendUint64(start, uint64(size))
+	if err := w.WriteFrame(TypeStart, start); err != nil {
+		return err
+	}
+	return finish(ctx, w, id, r, 0, size, onProgress)
+}
+
+// finish sends the data from offset to size, then END, or CANCEL if that fails.
+func finish(ctx context.Context, w *enproto.FrameWriter, id ID, r io.Reader, offset, size int64, onProgress func(sent, total int64)) error {
+	if err := sendChunks(ctx, w, id, r, offset, size, onProgress); err != nil {
+		if werr := w.WriteFrame(TypeCancel, id[:]); werr != nil {
+			return errors.Join(err, werr)
+		}
+		return err
+	}
+	return w.WriteFrame(TypeEnd, id[:])
+}
+
+// ResumeRequest is a receiver's request, carried by a RESUME frame, to continue a
+// transfer from Offset.
+type ResumeRequest struct {
+	ID     ID
+	Offset int64
+}
+
+// ParseResume decodes the payload of a RESUME frame.
+func ParseResume(payload []byte) (ResumeRequest, error) {
+	if len(payload) != idSize+8 {
+		return ResumeRequest{}, ErrMalformed
+	}
+	return ResumeRequest{
+		ID:     ID(payload[:idSize]),
+		Offset: int64(binary.BigEndian.Uint64(payload[idSize:])),
+	}, nil
+}
+
+// ResumeSend answers a ResumeRequest for a transfer started with SendResumable,
+// sending the rest of r from the requested offset. onProgress counts from the start
+// of the message.
+func ResumeSend(ctx context.Context, w *enproto.FrameWriter, req ResumeRequest, r io.ReaderAt, size int64, onProgress func(sent, total int64)) error {
+	if req.Offset < 0 || req.Offset > size {
+		return fmt.Errorf("%w: resume at %d of %d bytes", ErrSizeMismatch, req.Offset, size)
+	}
+	return finish(ctx, w, req.ID, io.NewSectionReader(r, req.Offset, size-req.Offset), req.Offset, size, onProgress)
+}
+
+// sendChunks sends the data from offset to size, read from r, as CHUNK frames.
+func sendChunks(ctx context.Context, w *enproto.FrameWriter, id ID, r io.Reader, offset, size int64, onProgress func(sent, total int64)) error {
+	buf := make([]byte, idSize+8+int(min(ChunkSize, size-offset)))
+	copy(buf, id[:])
+	for offset < size {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n := min(int64(ChunkSize), size-offset)
+		binary.BigEndian.PutUint64(buf[idSize:], uint64(offset))
+		data := buf[idSize+8 : idSize+8+int(n)]
+		if _, err := io.ReadFull(r, data); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("%w: source ended at %d of %d bytes", ErrSizeMismatch, offset, size)
+			}
+			return err
+		}
+		if err := w.WriteFrame(TypeChunk, buf[:idSize+8+int(n)]); err != nil {
+			return err
+		}
+
+		offset += n
+		if onProgress != nil {
+			onProgress(offset, size)
+		}
+	}
+	return nil
+}
+
+// ReceiveLargeMessage reads one transfer from fr, writing its data to dst, and
+// returns the message type. onProgress, if not nil, is called after every chunk with
+// the bytes received so far. Any frame outside the transfer fails with
+// ErrUnexpectedFrame. ctx is checked between frames; close the connection to abort a
+// read that is blocked.
+func ReceiveLargeMessage(ctx context.Context, fr *enproto.FrameReader, dst io.Writer, onProgress func(received, total int64)) (msgType byte, err error) {
+	var cp Checkpoint
+	if err := Receive(ctx, fr, dst, &cp, onProgress); err != nil {
+		return 0, err
+	}
+	return cp.Type, nil
+}
+
+// Checkpoint records a transfer's progress on the receiving side, so that it can be
+// resumed after the connection is lost.
+type Checkpoint struct {
+	ID       ID
+	Type     byte
+	Size     int64
+	Received int64 // bytes written to the destination so far
+}
+
+// RequestResume asks the sender, over a new connection, to continue the transfer
+// recorded in cp. Follow it with Receive using the same cp.
+func RequestResume(w *enproto.FrameWriter, cp *Checkpoint) error {
+	req := make([]byte, 0, idSize+8)
+	req = append(req, cp.ID[:]...)
+	req = binary.BigEndian.AppendUint64(req, uint64(cp.Received))
+	return w.WriteFrame(TypeResume, req)
+}
+
+// Receive is ReceiveLargeMessage keeping its progress in cp. With a zero cp it reads
+// a new transfer from its START frame. With a cp from an interrupted Receive it
+// continues that transfer from cp.Received, after RequestResume. When Receive fails,
+// cp holds what has been written to dst.
+func Receive(ctx context.Context, fr *enproto.FrameReader, dst io.Writer, cp *Checkpoint, onProgress func(received, total int64)) error {
+	if cp.ID == (ID{}) {
+		if err := readStart(ctx, fr, cp); err != nil {
+			return err
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		t, payload, err := fr.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if len(payload) < idSize || ID(payload[:idSize]) != cp.ID {
+			return fmt.Errorf("%w: type %d outside transfer", ErrUnexpectedFrame, t)
+		}
+
+		switch t {
+		case TypeChunk:
+			if len(payload) < idSize+8 {
+				return ErrMalformed
+			}
+			offset := int64(binary.BigEndian.Uint64(payload[idSize:]))
+			data := payload[idSize+8:]
+			if offset != cp.Received || cp.Received+int64(len(data)) > cp.Size {
+				return fmt.Errorf("%w: chunk at %d with %d bytes after %d of %d", ErrSizeMismatch, offset, len(data), cp.Received, cp.Size)
+			}
+			if _, err := dst.Write(data); err != nil {
+				return err
+			}
+			cp.Received += int64(len(data))
+			if onProgress != nil {
+				onProgress(cp.Received, cp.Size)
+			}
+
+		case TypeEnd:
+			if cp.Received != cp.Size {
+				return fmt.Errorf("%w: ended at %d of %d bytes", ErrSizeMismatch, cp.Received, cp.Size)
+			}
+			return nil
+
+		case TypeCancel:
+			return ErrCanceled
+
+		default:
+			return fmt.Errorf("%w: type %d during transfer", ErrUnexpectedFrame, t)
+		}
+	}
+}
+
+// readStart reads a START frame into cp.
+func readStart(ctx context.Context, fr *enproto.FrameReader, cp *Checkpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t, payload, err := fr.ReadFrame()
+	if err != nil {
+		return err
+	}
+	if t != TypeStart {
+		return fmt.Errorf("%w: type %d before START", ErrUnexpectedFrame, t)
+	}
+	if len(payload) != idSize+1+8 {
+		return ErrMalformed
+	}
+	*cp = Checkpoint{
+		ID:   ID(payload[:idSize]),
+		Type: payload[idSize],
+		Size: int64(binary.BigEndian.Uint64(payload[idSize+1:])),
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/ianchildress/enproto"
+)
+
+// TestSendReceive verifies a multi-chunk message arrives intact with progress.
+func TestSendReceive(t *testing.T) {
+	data := make([]byte, 3*ChunkSize+123)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	var wire bytes.Buffer
+	var sentCalls int
+	err := SendLargeMessage(context.Background(), enproto.NewFrameWriter(&wire), 0x42,
+		bytes.NewReader(data), int64(len(data)), func(sent, total int64) { sentCalls++ })
+	if err != nil {
+		t.Fatalf("SendLargeMessage: %v", err)
+	}
+	if sentCalls != 4 {
+		t.Errorf("sender progress called %d times; want 4", sentCalls)
+	}
+
+	var got bytes.Buffer
+	var last int64
+	msgType, err := ReceiveLargeMessage(context.Background(), enproto.NewFrameReader(&wire), &got,
+		func(received, total int64) {
+			if received <= last || total != int64(len(data)) {
+				t.Errorf("progress %d/%d after %d", received, total, last)
+			}
+			last = received
+		})
+	if err != nil {
+		t.Fatalf("ReceiveLargeMessage: %v", err)
+	}
+	if msgType != 0x42 || !bytes.Equal(got.Bytes(), data) {
+		t.Errorf("received type %d, %d bytes; want type 0x42, %d bytes", msgType, got.Len(), len(data))
+	}
+}
+
+// TestSend_ShortSource verifies a source that ends early cancels the transfer.
+func TestSend_ShortSource(t *testing.T) {
+	var wire bytes.Buffer
+	err := SendLargeMessage(context.Background(), enproto.NewFrameWriter(&wire), 0x01,
+		bytes.NewReader(make([]byte, 10)), 100, nil)
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("SendLargeMessage error = %v; want %v", err, ErrSizeMismatch)
+	}
+
+	_, err = ReceiveLargeMessage(context.Background(), enproto.NewFrameReader(&wire), io.Discard, nil)
+	if !errors.Is(err, ErrCanceled) {
+		t.Errorf("ReceiveLargeMessage error = %v; want %v", err, ErrCanceled)
+	}
+}
+
+// TestSend_Cancel verifies canceling the context mid-transfer stops both ends.
+func TestSend_Cancel(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- SendLargeMessage(ctx, enproto.NewFrameWriter(client), 0x01,
+			bytes.NewReader(make([]byte, 10*ChunkSize)), 10*ChunkSize,
+			func(sent, total int64) {
+				if sent >= 2*ChunkSize {
+					cancel()
+				}
+			})
+	}()
+
+	_, err := ReceiveLargeMessage(context.Background(), enproto.NewFrameReader(server), io.Discard, nil)
+	if !errors.Is(err, ErrCanceled) {
+		t.Errorf("ReceiveLargeMessage error = %v; want %v", err, ErrCanceled)
+	}
+	if err := <-sendErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("SendLargeMessage error = %v; want %v", err, context.Canceled)
+	}
+}
+
+// TestReceive_Unexpected verifies frames outside a transfer are rejected.
+func TestReceive_Unexpected(t *testing.T) {
+	var wire bytes.Buffer
+	enproto.NewFrameWriter(&wire).WriteFrame(0x01, []byte("not a transfer"))
+
+	_, err := ReceiveLargeMessage(context.Background(), enproto.NewFrameReader(&wire), io.Discard, nil)
+	if !errors.Is(err, ErrUnexpectedFrame) {
+		t.Errorf("ReceiveLargeMessage error = %v; want %v", err, ErrUnexpectedFrame)
+	}
+}
+
+// TestResume verifies a transfer cut off mid-way continues on a new connection
+// from the receiver's checkpoint.
+func TestResume(t *testing.T) {
+	data := make([]byte, 5*ChunkSize)
+	rand.New(rand.NewSource(2)).Read(data)
+	src := bytes.NewReader(data)
+	id, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID: %v", err)
+	}
+
+	// First connection: dropped after two chunks.
+	client, server := net.Pipe()
+	go SendResumable(context.Background(), enproto.NewFrameWriter(client), id, 0x07, src, int64(len(data)), nil)
+
+	var got bytes.Buffer
+	var cp Checkpoint
+	err = Receive(context.Background(), enproto.NewFrameReader(server), &got, &cp, func(received, total int64) {
+		if received == 2*ChunkSize {
+			server.Close()
+			client.Close()
+		}
+	})
+	if err == nil {
+		t.Fatalf("Receive on a dropped connection succeeded")
+	}
+	if cp.ID != id || cp.Received != 2*ChunkSize || cp.Type != 0x07 {
+		t.Fatalf("checkpoint = %+v; want ID %x at %d bytes", cp, id, 2*ChunkSize)
+	}
+
+	// Second connection: the receiver asks to resume and the sender answers.
+	var request bytes.Buffer
+	if err := RequestResume(enproto.NewFrameWriter(&request), &cp); err != nil {
+		t.Fatalf("RequestResume: %v", err)
+	}
+	msgType, payload, err := enproto.NewFrameReader(&request).ReadFrame()
+	if err != nil || msgType != TypeResume {
+		t.Fatalf("read RESUME = %d, %v; want type %d", msgType, err, TypeResume)
+	}
+	req, err := ParseResume(payload)
+	if err != nil {
+		t.Fatalf("ParseResume: %v", err)
+	}
+
+	var rest bytes.Buffer
+	if err := ResumeSend(context.Background(), enproto.NewFrameWriter(&rest), req, src, int64(len(data)), nil); err != nil {
+		t.Fatalf("ResumeSend: %v", err)
+	}
+	if err := Receive(context.Background(), enproto.NewFrameReader(&rest), &got, &cp, nil); err != nil {
+		t.Fatalf("resumed Receive: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Errorf("resumed transfer produced %d bytes differing from the %d sent", got.Len(), len(data))
+	}
+}
@@ -0,0 +1,116 @@
+package enproto
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// FrameWriter writes our length‐prefixed, versioned frames to an io.Writer.
+type FrameWriter struct {
+	w  io.Writer // the transport, for vectored writes that bypass bw
+	bw *bufio.Writer
+
+	cfg config
+}
+
+// NewFrameWriter wraps w with the write half of our framing logic, configured by opts.
+func NewFrameWriter(w io.Writer, opts ...Option) *FrameWriter {
+	return newFrameWriter(w, newConfig(opts))
+}
+
+func newFrameWriter(w io.Writer, cfg config) *FrameWriter {
+	return &FrameWriter{
+		w:   w,
+		bw:  bufio.NewWriterSize(w, cfg.writeBufferSize),
+		cfg: cfg,
+	}
+}
+
+// WriteFrame writes a frame and flushes immediately (compat behavior).
+func (w *FrameWriter) WriteFrame(msgType byte, payload []byte) error {
+	if err := w.WriteFrameBuffered(msgType, payload); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
+
+// WriteFrameBuffered writes a frame to the internal buffer.
+// Call Flush to ensure data is sent to the underlying writer.
+func (w *FrameWriter) WriteFrameBuffered(msgType byte, payload []byte) error {
+	if uint64(len(payload)) > uint64(w.cfg.maxFrameSize) {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, len(payload))
+	}
+
+	var header [8]byte
+	binary.BigEndian.PutUint16(header[0:2], w.cfg.magic)
+	header[2] = w.cfg.version
+	header[3] = msgType
+	binary.BigEndian.PutUint32(header[4:8], uint32(len(payload)))
+
+	if _, err := w.bw.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.bw.Write(payload); err != nil {
+		return err
+	}
+	w.cfg.counters.wrote(uint64(len(payload)))
+
+	return nil
+}
+
+// WriteFrameV writes a frame whose payload is the concatenation of bufs and flushes,
+// without first copying the segments into one slice. Frames that fit in the write
+// buffer are buffered as usual; larger ones are handed to the transport as a single
+// vectored write (writev on a net.Conn) after whatever is already buffered.
+func (w *FrameWriter) WriteFrameV(msgType byte, bufs ...[]byte) error {
+	var total uint64
+	for _, b := range bufs {
+		total += uint64(len(b))
+	}
+	if total > uint64(w.cfg.maxFrameSize) {
+		return fmt.Errorf("%w: %d", ErrFrameTooLarge, total)
+	}
+
+	var header [8]byte
+	binary.BigEndian.PutUint16(header[0:2], w.cfg.magic)
+	header[2] = w.cfg.version
+	header[3] = msgType
+	binary.BigEndian.PutUint32(header[4:8], uint32(total))
+
+	if uint64(len(header))+total <= uint64(w.bw.Available()) {
+		w.bw.Write(header[:]) // fits, so cannot fail short of a sticky error
+		for _, b := range bufs {
+			w.bw.Write(b)
+		}
+		w.cfg.counters.wrote(total)
+		return w.bw.Flush()
+	}
+
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	vec := make(net.Buffers, 0, 1+len(bufs))
+	vec = append(vec, header[:])
+	vec = append(vec, bufs...)
+	if _, err := vec.WriteTo(w.w); err != nil {
+		return err
+	}
+	w.cfg.counters.wrote(total)
+	return nil
+}
+
+// Flush flushes the buffered writer.
+func (w *FrameWriter) Flush() error {
+	return w.bw.Flush()
+}
+
+// WriteBuffered returns the number of bytes currently queued in the write buffer.
+func (w *FrameWriter) WriteBuffered() int {
+	if w == nil || w.bw == nil {
+		return 0
+	}
+	return w.bw.Buffered()
+}
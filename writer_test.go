@@ -0,0 +1,90 @@
+package enproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// writeOnly hides every method but Write, like a log shipper's output sink.
+type writeOnly struct{ buf *bytes.Buffer }
+
+func (w writeOnly) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+// TestFrameWriter_WriteOnly verifies a FrameWriter works over a plain io.Writer.
+func TestFrameWriter_WriteOnly(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := NewFrameWriter(writeOnly{buf})
+
+	payload := []byte("write only")
+	if err := fw.WriteFrame(0x5, payload); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	wire := buf.Bytes()
+	if len(wire) != 8+len(payload) {
+		t.Fatalf("wrote %d bytes; want %d", len(wire), 8+len(payload))
+	}
+	if magic := binary.BigEndian.Uint16(wire[0:2]); magic != Magic {
+		t.Errorf("magic = %#x; want %#x", magic, Magic)
+	}
+	if wire[3] != 0x5 {
+		t.Errorf("message type = %d; want %d", wire[3], 0x5)
+	}
+	if !bytes.Equal(wire[8:], payload) {
+		t.Errorf("payload = %q; want %q", wire[8:], payload)
+	}
+}
+
+// TestFrameWriter_WriteFrameV checks that segmented payloads arrive as one frame,
+// both when they fit in the write buffer and when they bypass it.
+func TestFrameWriter_WriteFrameV(t *testing.T) {
+	big := bytes.Repeat([]byte("b"), 300)
+	tests := []struct {
+		name string
+		bufs [][]byte
+	}{
+		{"buffered", [][]byte{[]byte("hello, "), nil, []byte("world")}},
+		{"vectored", [][]byte{[]byte("head"), big, []byte("tail")}},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			fw := NewFrameWriter(buf, WithWriteBufferSize(64))
+			if err := fw.WriteFrameBuffered(0x1, []byte("first")); err != nil {
+				t.Fatalf("WriteFrameBuffered error: %v", err)
+			}
+			if err := fw.WriteFrameV(0x2, tt.bufs...); err != nil {
+				t.Fatalf("WriteFrameV error: %v", err)
+			}
+
+			fr := NewFrameReader(buf)
+			if _, p, err := fr.ReadFrame(); err != nil || string(p) != "first" {
+				t.Fatalf("first frame = %q, %v; want %q", p, err, "first")
+			}
+			msgType, p, err := fr.ReadFrame()
+			if err != nil {
+				t.Fatalf("ReadFrame error: %v", err)
+			}
+			want := bytes.Join(tt.bufs, nil)
+			if msgType != 0x2 || !bytes.Equal(p, want) {
+				t.Errorf("frame = %d %q; want %d %q", msgType, p, 0x2, want)
+			}
+		})
+	}
+}
+
+// TestFrameWriter_WriteFrameV_TooLarge verifies the size limit covers all segments.
+func TestFrameWriter_WriteFrameV_TooLarge(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fw := NewFrameWriter(buf, WithMaxFrameSize(8))
+	err := fw.WriteFrameV(0x1, []byte("12345"), []byte("6789"))
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("WriteFrameV error = %v; want %v", err, ErrFrameTooLarge)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %d bytes for a rejected frame; want 0", buf.Len())
+	}
+}